@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
-	"log"
 	"math"
 	embedded_helpers "orth/cmd/core/embedded/helpers"
 	"orth/cmd/core/orth_debug"
@@ -19,20 +18,30 @@ import (
 const MASM_MAX_8BIT_CHAR_PER_LINE float64 = 20.0
 
 // Compile compiles a program into assembly
-func Compile(program orth_types.Program, assemblyType string) {
+func Compile(program orth_types.Program, assemblyType string) (err error) {
 	orth_debug.LogStep("[INFO] Started compilation workflow")
 
 	if assemblyType != "masm" {
-		panic("[TEMP]: the current supported assembly is MASM")
+		return fmt.Errorf("[TEMP]: the current supported assembly is MASM\n")
 	}
 
 	finalAsm := fmt.Sprintf("%s.asm", *orth_debug.ObjectName)
 
 	output, err := os.Create(finalAsm)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("%w\n", err)
 	}
 
+	// the code generation panics with an error on operations it can not write
+	defer func() {
+		if r := recover(); r != nil {
+			recovered, ok := r.(error)
+			if !ok {
+				panic(r)
+			}
+			err = fmt.Errorf("%w\n", recovered)
+		}
+	}()
 	compileMasm(program, output)
 
 	if !*orth_debug.NoLink {
@@ -44,17 +53,17 @@ func Compile(program orth_types.Program, assemblyType string) {
 		compileCmd.Stdout = &stdout
 
 		if err = compileCmd.Run(); err != nil {
-			fmt.Println(stdout.String())
-			os.Exit(1)
+			return fmt.Errorf("%s%w\n", stdout.String(), err)
 		}
 	}
 	orth_debug.LogStep("[CMD] Finished running ML64")
 
 	if *orth_debug.UnclearFiles || *orth_debug.NoLink {
 		orth_debug.LogStep("[CMD] UCLR or NL flag active, files won't be deleted")
-		return
+		return nil
 	}
 	embedded_helpers.CleanUp()
+	return nil
 }
 
 func compileMasm(program orth_types.Program, output *os.File) {
@@ -64,6 +73,20 @@ func compileMasm(program orth_types.Program, output *os.File) {
 	// basic header stuff
 	writer := bufio.NewWriter(output)
 	writer.WriteString("include C:\\masm64\\include64\\masm64rt.inc\n")
+	for _, lib := range strings.Split(*orth_debug.Libs, ",") {
+		if lib = strings.TrimSpace(lib); lib != "" {
+			writer.WriteString(fmt.Sprintf("includelib %s\n", lib))
+		}
+	}
+	for _, extern := range program.Operations {
+		if extern.Instruction != orth_types.InstructionExtern {
+			continue
+		}
+		// functions from the libraries of masm64rt are already declared
+		writer.WriteString(fmt.Sprintf("IFNDEF %s\n", extern.Operator.Operand))
+		writer.WriteString(fmt.Sprintf("	externdef %s:PROC\n", extern.Operator.Operand))
+		writer.WriteString("ENDIF\n")
+	}
 
 	// data segment (pre-defined)
 	writer.WriteString(".DATA\n")
@@ -82,19 +105,25 @@ func compileMasm(program orth_types.Program, output *os.File) {
 	}
 	writer.Flush()
 
-	writer.WriteString("\n.DATA ; MultScoped constants\n")
+	// constants are read-only, writing to them through a pointer faults
+	writer.WriteString("\n.CONST ; MultScoped constants\n")
 	for _, variable := range program.Constants {
 		asmVar := embedded_helpers.BuildVarDataSeg(variable)
 		writer.WriteString(fmt.Sprintf("	%s\n", asmVar))
 	}
-	writer.WriteString("\n")
+	writer.WriteString("\n.DATA\n")
 
 	writer.WriteString("	nArgc QWORD 0\n")
+	writer.WriteString("	envp QWORD 0\n")
 	writer.WriteString("	lError QWORD 0\n")
+	writer.WriteString("	hStdIn QWORD 0\n")
 
 	// data segment (undefined)
 	writer.WriteString(".DATA?\n")
-	writer.WriteString("	mem  BYTE 640000 dup(?)\n")
+	writer.WriteString(fmt.Sprintf("	mem  BYTE %d dup(?)\n", program.MemCapacity()))
+	for _, region := range program.MemoryRegions {
+		writer.WriteString(fmt.Sprintf("	%s BYTE %s dup(?)\n", embedded_helpers.MangleVarName(region), region.Links["memory_size"].Operator.Operand))
+	}
 	writer.WriteString("	trash QWORD ?\n")
 
 	// code segment
@@ -106,6 +135,16 @@ func compileMasm(program orth_types.Program, output *os.File) {
 	writer.WriteString("	invoke StdOut, lError\n")
 	writer.WriteString("	invoke ExitProcess, 1\n")
 
+	writer.WriteString("; no return label\n")
+	writer.WriteString("array_index_out_of_bounds:\n")
+	writer.WriteString("	invoke StdOut, chr$(\"RNT_ERR: array index out of bounds\", 13, 10)\n")
+	writer.WriteString("	invoke ExitProcess, 1\n")
+
+	writer.WriteString("; no return label\n")
+	writer.WriteString("exit_status_out_of_range:\n")
+	writer.WriteString("	invoke StdOut, chr$(\"RNT_ERR: exit status out of range\", 13, 10)\n")
+	writer.WriteString("	invoke ExitProcess, 1\n")
+
 	writer.WriteString("clear_proc_params PROC\n")
 	for i := 0; i < 32; i++ {
 		writer.WriteString(fmt.Sprintf("	mov proc_arg_%d, 0\n", i))
@@ -183,6 +222,423 @@ func compileMasm(program orth_types.Program, output *os.File) {
 	writer.WriteString("	mfree   pBuff  ; Free the allocated memory.\n")
 	writer.WriteString("	ret\n")
 	writer.WriteString("put_char endp\n")
+	writer.WriteString("; RCX: handle, 0, 1 and 2 are stdin, stdout and stderr\n")
+	writer.WriteString("resolve_handle proc\n")
+	writer.WriteString("	cmp     rcx, 2\n")
+	writer.WriteString("	ja      .rh_file\n")
+	writer.WriteString("	mov     rax, STD_INPUT_HANDLE	; STD_OUTPUT_HANDLE and STD_ERROR_HANDLE follow it decreasing\n")
+	writer.WriteString("	sub     rax, rcx\n")
+	writer.WriteString("	invoke  GetStdHandle, rax\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".rh_file:\n")
+	writer.WriteString("	mov     rax, rcx\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("resolve_handle endp\n")
+	writer.WriteString("; RCX: path, RDX: mode (0 read, 1 write, 2 append)\n")
+	writer.WriteString("f_open proc\n")
+	writer.WriteString("	LOCAL pPath     :QWORD\n")
+	writer.WriteString("	mov     pPath, rcx\n")
+	writer.WriteString("	cmp     rdx, 1\n")
+	writer.WriteString("	je      .fo_write\n")
+	writer.WriteString("	cmp     rdx, 2\n")
+	writer.WriteString("	je      .fo_append\n")
+	writer.WriteString("	invoke  CreateFileA, pPath, GENERIC_READ, FILE_SHARE_READ, 0, OPEN_EXISTING, FILE_ATTRIBUTE_NORMAL, 0\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".fo_write:\n")
+	writer.WriteString("	invoke  CreateFileA, pPath, GENERIC_WRITE, 0, 0, CREATE_ALWAYS, FILE_ATTRIBUTE_NORMAL, 0\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".fo_append:\n")
+	writer.WriteString("	invoke  CreateFileA, pPath, FILE_APPEND_DATA, FILE_SHARE_READ, 0, OPEN_ALWAYS, FILE_ATTRIBUTE_NORMAL, 0\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("f_open endp\n")
+	writer.WriteString("; RCX: handle, RDX: buffer, R8: amount of bytes\n")
+	writer.WriteString("f_read proc\n")
+	writer.WriteString("	LOCAL pBuff     :QWORD\n")
+	writer.WriteString("	LOCAL nBytes    :QWORD\n")
+	writer.WriteString("	LOCAL nRead     :QWORD\n")
+	writer.WriteString("	mov     pBuff, rdx\n")
+	writer.WriteString("	mov     nBytes, r8\n")
+	writer.WriteString("	mov     nRead, 0\n")
+	writer.WriteString("	invoke  resolve_handle\n")
+	writer.WriteString("	invoke  ReadFile, rax, pBuff, nBytes, addr nRead, 0\n")
+	writer.WriteString("	test    rax, rax\n")
+	writer.WriteString("	jz      .fr_error\n")
+	writer.WriteString("	mov     rax, nRead\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".fr_error:\n")
+	writer.WriteString("	mov     rax, -1\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("f_read endp\n")
+	writer.WriteString("; RCX: handle, RDX: buffer, R8: amount of bytes\n")
+	writer.WriteString("f_write proc\n")
+	writer.WriteString("	LOCAL pBuff     :QWORD\n")
+	writer.WriteString("	LOCAL nBytes    :QWORD\n")
+	writer.WriteString("	LOCAL nWritten  :QWORD\n")
+	writer.WriteString("	mov     pBuff, rdx\n")
+	writer.WriteString("	mov     nBytes, r8\n")
+	writer.WriteString("	mov     nWritten, 0\n")
+	writer.WriteString("	invoke  resolve_handle\n")
+	writer.WriteString("	invoke  WriteFile, rax, pBuff, nBytes, addr nWritten, 0\n")
+	writer.WriteString("	test    rax, rax\n")
+	writer.WriteString("	jz      .fw_error\n")
+	writer.WriteString("	mov     rax, nWritten\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".fw_error:\n")
+	writer.WriteString("	mov     rax, -1\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("f_write endp\n")
+	writer.WriteString("; RCX: handle\n")
+	writer.WriteString("f_close proc\n")
+	writer.WriteString("	invoke  resolve_handle\n")
+	writer.WriteString("	invoke  CloseHandle, rax\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("f_close endp\n")
+	writer.WriteString("; RCX: handle\n")
+	writer.WriteString("f_size proc\n")
+	writer.WriteString("	LOCAL nSize     :QWORD\n")
+	writer.WriteString("	invoke  resolve_handle\n")
+	writer.WriteString("	invoke  GetFileSizeEx, rax, addr nSize\n")
+	writer.WriteString("	test    rax, rax\n")
+	writer.WriteString("	jz      .fs_error\n")
+	writer.WriteString("	mov     rax, nSize\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".fs_error:\n")
+	writer.WriteString("	mov     rax, -1\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("f_size endp\n")
+	writer.WriteString("; RCX: path\n")
+	writer.WriteString("f_unlink proc\n")
+	writer.WriteString("	invoke  DeleteFileA, rcx\n")
+	writer.WriteString("	test    rax, rax\n")
+	writer.WriteString("	jz      .fu_error\n")
+	writer.WriteString("	xor     rax, rax\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".fu_error:\n")
+	writer.WriteString("	mov     rax, -1\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("f_unlink endp\n")
+	writer.WriteString("; RCX: handle, RDX: null terminated string\n")
+	writer.WriteString("puts_fd proc\n")
+	writer.WriteString("	LOCAL pStr      :QWORD\n")
+	writer.WriteString("	LOCAL hHandle   :QWORD\n")
+	writer.WriteString("	LOCAL nWritten  :QWORD\n")
+	writer.WriteString("	mov     pStr, rdx\n")
+	writer.WriteString("	invoke  resolve_handle\n")
+	writer.WriteString("	mov     hHandle, rax\n")
+	writer.WriteString("	invoke  lstrlenA, pStr\n")
+	writer.WriteString("	invoke  WriteFile, hHandle, pStr, rax, addr nWritten, 0\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("puts_fd endp\n")
+	writer.WriteString("; returns the next byte of stdin or -1 at its end\n")
+	writer.WriteString("get_char proc\n")
+	writer.WriteString("	LOCAL cChar     :QWORD\n")
+	writer.WriteString("	LOCAL nRead     :QWORD\n")
+	writer.WriteString("	cmp     hStdIn, 0				; the handle is asked only on the first read\n")
+	writer.WriteString("	jne     .gc_read\n")
+	writer.WriteString("	invoke  GetStdHandle, STD_INPUT_HANDLE\n")
+	writer.WriteString("	mov     hStdIn, rax\n")
+	writer.WriteString(".gc_read:\n")
+	writer.WriteString("	mov     cChar, 0\n")
+	writer.WriteString("	mov     nRead, 0\n")
+	writer.WriteString("	invoke  ReadFile, hStdIn, addr cChar, 1, addr nRead, 0\n")
+	writer.WriteString("	test    rax, rax\n")
+	writer.WriteString("	jz      .gc_end\n")
+	writer.WriteString("	cmp     nRead, 0\n")
+	writer.WriteString("	je      .gc_end\n")
+	writer.WriteString("	mov     rax, cChar\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".gc_end:\n")
+	writer.WriteString("	mov     rax, -1\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("get_char endp\n")
+	writer.WriteString("; RCX: buffer, RDX: capacity of the buffer\n")
+	writer.WriteString("read_line proc\n")
+	writer.WriteString("	LOCAL pBuff     :QWORD\n")
+	writer.WriteString("	LOCAL nCap      :QWORD\n")
+	writer.WriteString("	LOCAL nLen      :QWORD\n")
+	writer.WriteString("	mov     pBuff, rcx\n")
+	writer.WriteString("	mov     nCap, rdx\n")
+	writer.WriteString("	mov     nLen, 0\n")
+	writer.WriteString(".rl_next:\n")
+	writer.WriteString("	invoke  get_char\n")
+	writer.WriteString("	cmp     rax, -1\n")
+	writer.WriteString("	je      .rl_end\n")
+	writer.WriteString("	cmp     rax, 10\n")
+	writer.WriteString("	je      .rl_done\n")
+	writer.WriteString("	cmp     rax, 13\n")
+	writer.WriteString("	je      .rl_next\n")
+	writer.WriteString("	mov     rcx, nLen\n")
+	writer.WriteString("	cmp     rcx, nCap\n")
+	writer.WriteString("	jae     .rl_next				; whatever does not fit the buffer is discarded\n")
+	writer.WriteString("	mov     rdx, pBuff\n")
+	writer.WriteString("	mov     BYTE PTR [rdx+rcx], al\n")
+	writer.WriteString("	inc     nLen\n")
+	writer.WriteString("	jmp     .rl_next\n")
+	writer.WriteString(".rl_end:\n")
+	writer.WriteString("	cmp     nLen, 0\n")
+	writer.WriteString("	jne     .rl_done\n")
+	writer.WriteString("	mov     rax, -1\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".rl_done:\n")
+	writer.WriteString("	mov     rcx, nLen\n")
+	writer.WriteString("	cmp     rcx, nCap\n")
+	writer.WriteString("	jae     .rl_length\n")
+	writer.WriteString("	mov     rdx, pBuff\n")
+	writer.WriteString("	mov     BYTE PTR [rdx+rcx], 0\n")
+	writer.WriteString(".rl_length:\n")
+	writer.WriteString("	mov     rax, nLen\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("read_line endp\n")
+	writer.WriteString("; reads a signed decimal from stdin, discarding the rest of its line\n")
+	writer.WriteString("read_int proc\n")
+	writer.WriteString("	LOCAL nValue    :QWORD\n")
+	writer.WriteString("	LOCAL nSign     :QWORD\n")
+	writer.WriteString("	mov     nValue, 0\n")
+	writer.WriteString("	mov     nSign, 1\n")
+	writer.WriteString(".ri_blank:\n")
+	writer.WriteString("	invoke  get_char\n")
+	writer.WriteString("	cmp     rax, -1\n")
+	writer.WriteString("	je      .ri_end\n")
+	writer.WriteString("	cmp     rax, 32\n")
+	writer.WriteString("	jbe     .ri_blank\n")
+	writer.WriteString("	cmp     rax, 45\n")
+	writer.WriteString("	jne     .ri_digit\n")
+	writer.WriteString("	mov     nSign, -1\n")
+	writer.WriteString(".ri_next:\n")
+	writer.WriteString("	invoke  get_char\n")
+	writer.WriteString(".ri_digit:\n")
+	writer.WriteString("	cmp     rax, 48\n")
+	writer.WriteString("	jl      .ri_rest\n")
+	writer.WriteString("	cmp     rax, 57\n")
+	writer.WriteString("	jg      .ri_rest\n")
+	writer.WriteString("	sub     rax, 48\n")
+	writer.WriteString("	mov     rcx, nValue\n")
+	writer.WriteString("	imul    rcx, 10\n")
+	writer.WriteString("	add     rcx, rax\n")
+	writer.WriteString("	mov     nValue, rcx\n")
+	writer.WriteString("	jmp     .ri_next\n")
+	writer.WriteString(".ri_rest:\n")
+	writer.WriteString("	cmp     rax, 10\n")
+	writer.WriteString("	je      .ri_end\n")
+	writer.WriteString("	cmp     rax, -1\n")
+	writer.WriteString("	je      .ri_end\n")
+	writer.WriteString("	invoke  get_char\n")
+	writer.WriteString("	jmp     .ri_rest\n")
+	writer.WriteString(".ri_end:\n")
+	writer.WriteString("	mov     rax, nValue\n")
+	writer.WriteString("	imul    rax, nSign\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("read_int endp\n")
+	writer.WriteString("; RCX: value, RDX: base, R8: 1 when signed, R9: minimum width padded with spaces\n")
+	writer.WriteString("put_number proc\n")
+	writer.WriteString("	LOCAL buffer[136]: byte\n")
+	writer.WriteString("	LOCAL nBase     :QWORD\n")
+	writer.WriteString("	LOCAL nWidth    :QWORD\n")
+	writer.WriteString("	LOCAL bNegative :QWORD\n")
+	writer.WriteString("	mov     nBase, rdx\n")
+	writer.WriteString("	mov     nWidth, r9\n")
+	writer.WriteString("	mov     bNegative, 0\n")
+	writer.WriteString("	lea     r10, buffer\n")
+	writer.WriteString("	add     r10, 135\n")
+	writer.WriteString("	mov     BYTE PTR [r10], 0\n")
+	writer.WriteString("	mov     rax, rcx\n")
+	writer.WriteString("	test    r8, r8\n")
+	writer.WriteString("	jz      .pn_digit\n")
+	writer.WriteString("	test    rax, rax\n")
+	writer.WriteString("	jns     .pn_digit\n")
+	writer.WriteString("	neg     rax\n")
+	writer.WriteString("	mov     bNegative, 1\n")
+	writer.WriteString(".pn_digit:\n")
+	writer.WriteString("	xor     rdx, rdx\n")
+	writer.WriteString("	div     nBase\n")
+	writer.WriteString("	add     dl, 48\n")
+	writer.WriteString("	cmp     dl, 57\n")
+	writer.WriteString("	jbe     .pn_store\n")
+	writer.WriteString("	add     dl, 39				; digits above 9 are lowercase letters\n")
+	writer.WriteString(".pn_store:\n")
+	writer.WriteString("	dec     r10\n")
+	writer.WriteString("	mov     [r10], dl\n")
+	writer.WriteString("	test    rax, rax\n")
+	writer.WriteString("	jnz     .pn_digit\n")
+	writer.WriteString("	cmp     bNegative, 0\n")
+	writer.WriteString("	je      .pn_pad\n")
+	writer.WriteString("	dec     r10\n")
+	writer.WriteString("	mov     BYTE PTR [r10], 45\n")
+	writer.WriteString(".pn_pad:\n")
+	writer.WriteString("	lea     rax, buffer\n")
+	writer.WriteString("	cmp     r10, rax\n")
+	writer.WriteString("	jbe     .pn_print\n")
+	writer.WriteString("	add     rax, 135\n")
+	writer.WriteString("	sub     rax, r10\n")
+	writer.WriteString("	cmp     rax, nWidth\n")
+	writer.WriteString("	jge     .pn_print\n")
+	writer.WriteString("	dec     r10\n")
+	writer.WriteString("	mov     BYTE PTR [r10], 32\n")
+	writer.WriteString("	jmp     .pn_pad\n")
+	writer.WriteString(".pn_print:\n")
+	writer.WriteString("	invoke  StdOut, r10\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("put_number endp\n")
+	writer.WriteString("; RCX: boolean\n")
+	writer.WriteString("put_bool proc\n")
+	writer.WriteString("	test    rcx, rcx\n")
+	writer.WriteString("	jz      .pb_false\n")
+	writer.WriteString("	invoke  StdOut, chr$(\"true\")\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".pb_false:\n")
+	writer.WriteString("	invoke  StdOut, chr$(\"false\")\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("put_bool endp\n")
+	writer.WriteString("; RCX: null terminated UTF-16 string, returns an allocated UTF-8 copy\n")
+	writer.WriteString("wide_to_utf8 proc\n")
+	writer.WriteString("	LOCAL pWide     :QWORD\n")
+	writer.WriteString("	LOCAL nBytes    :QWORD\n")
+	writer.WriteString("	LOCAL pUtf8     :QWORD\n")
+	writer.WriteString("	mov     pWide, rcx\n")
+	writer.WriteString("	invoke  WideCharToMultiByte, CP_UTF8, 0, pWide, -1, 0, 0, 0, 0\n")
+	writer.WriteString("	mov     nBytes, rax\n")
+	writer.WriteString("	mov     pUtf8, alloc(rax)\n")
+	writer.WriteString("	invoke  WideCharToMultiByte, CP_UTF8, 0, pWide, -1, pUtf8, nBytes, 0, 0\n")
+	writer.WriteString("	mov     rax, pUtf8\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("wide_to_utf8 endp\n")
+	writer.WriteString("; returns a null terminated array of UTF-8 arguments, nArgc holds its length\n")
+	writer.WriteString("build_argv proc\n")
+	writer.WriteString("	LOCAL pWide     :QWORD\n")
+	writer.WriteString("	LOCAL pArgv     :QWORD\n")
+	writer.WriteString("	LOCAL nIndex    :QWORD\n")
+	writer.WriteString("	invoke  GetCommandLineW\n")
+	writer.WriteString("	invoke  CommandLineToArgvW, rax, addr nArgc\n")
+	writer.WriteString("	mov     pWide, rax\n")
+	writer.WriteString("	mov     rax, nArgc\n")
+	writer.WriteString("	inc     rax\n")
+	writer.WriteString("	shl     rax, 3\n")
+	writer.WriteString("	mov     pArgv, alloc(rax)\n")
+	writer.WriteString("	mov     nIndex, 0\n")
+	writer.WriteString(".ba_next:\n")
+	writer.WriteString("	mov     rax, nIndex\n")
+	writer.WriteString("	cmp     rax, nArgc\n")
+	writer.WriteString("	jge     .ba_end\n")
+	writer.WriteString("	mov     rcx, pWide\n")
+	writer.WriteString("	mov     rcx, [rcx+rax*8]\n")
+	writer.WriteString("	invoke  wide_to_utf8\n")
+	writer.WriteString("	mov     rcx, pArgv\n")
+	writer.WriteString("	mov     rdx, nIndex\n")
+	writer.WriteString("	mov     [rcx+rdx*8], rax\n")
+	writer.WriteString("	inc     nIndex\n")
+	writer.WriteString("	jmp     .ba_next\n")
+	writer.WriteString(".ba_end:\n")
+	writer.WriteString("	mov     rcx, pArgv\n")
+	writer.WriteString("	mov     rdx, nArgc\n")
+	writer.WriteString("	mov     QWORD PTR [rcx+rdx*8], 0\n")
+	writer.WriteString("	invoke  LocalFree, pWide\n")
+	writer.WriteString("	mov     rax, pArgv\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("build_argv endp\n")
+	writer.WriteString("; returns a null terminated array of UTF-8 NAME=value strings, built only once\n")
+	writer.WriteString("build_envp proc\n")
+	writer.WriteString("	LOCAL pBlock    :QWORD\n")
+	writer.WriteString("	LOCAL pEntry    :QWORD\n")
+	writer.WriteString("	LOCAL nCount    :QWORD\n")
+	writer.WriteString("	LOCAL nIndex    :QWORD\n")
+	writer.WriteString("	cmp     envp, 0\n")
+	writer.WriteString("	je      .be_build\n")
+	writer.WriteString("	mov     rax, envp\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".be_build:\n")
+	writer.WriteString("	invoke  GetEnvironmentStringsW\n")
+	writer.WriteString("	mov     pBlock, rax\n")
+	writer.WriteString("	mov     nCount, 0\n")
+	writer.WriteString("	mov     rcx, rax\n")
+	writer.WriteString(".be_count:\n")
+	writer.WriteString("	cmp     WORD PTR [rcx], 0\n")
+	writer.WriteString("	je      .be_counted\n")
+	writer.WriteString("	inc     nCount\n")
+	writer.WriteString(".be_count_entry:\n")
+	writer.WriteString("	add     rcx, 2\n")
+	writer.WriteString("	cmp     WORD PTR [rcx-2], 0\n")
+	writer.WriteString("	jne     .be_count_entry\n")
+	writer.WriteString("	jmp     .be_count\n")
+	writer.WriteString(".be_counted:\n")
+	writer.WriteString("	mov     rax, nCount\n")
+	writer.WriteString("	inc     rax\n")
+	writer.WriteString("	shl     rax, 3\n")
+	writer.WriteString("	mov     envp, alloc(rax)\n")
+	writer.WriteString("	mov     rax, pBlock\n")
+	writer.WriteString("	mov     pEntry, rax\n")
+	writer.WriteString("	mov     nIndex, 0\n")
+	writer.WriteString(".be_next:\n")
+	writer.WriteString("	mov     rcx, pEntry\n")
+	writer.WriteString("	cmp     WORD PTR [rcx], 0\n")
+	writer.WriteString("	je      .be_end\n")
+	writer.WriteString("	invoke  wide_to_utf8\n")
+	writer.WriteString("	mov     rcx, envp\n")
+	writer.WriteString("	mov     rdx, nIndex\n")
+	writer.WriteString("	mov     [rcx+rdx*8], rax\n")
+	writer.WriteString("	inc     nIndex\n")
+	writer.WriteString("	mov     rcx, pEntry\n")
+	writer.WriteString(".be_skip_entry:\n")
+	writer.WriteString("	add     rcx, 2\n")
+	writer.WriteString("	cmp     WORD PTR [rcx-2], 0\n")
+	writer.WriteString("	jne     .be_skip_entry\n")
+	writer.WriteString("	mov     pEntry, rcx\n")
+	writer.WriteString("	jmp     .be_next\n")
+	writer.WriteString(".be_end:\n")
+	writer.WriteString("	mov     rcx, envp\n")
+	writer.WriteString("	mov     rdx, nIndex\n")
+	writer.WriteString("	mov     QWORD PTR [rcx+rdx*8], 0\n")
+	writer.WriteString("	invoke  FreeEnvironmentStringsW, pBlock\n")
+	writer.WriteString("	mov     rax, envp\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("build_envp endp\n")
+	writer.WriteString("; RCX: name of the variable, returns a pointer to its value or 0\n")
+	writer.WriteString("get_env proc\n")
+	writer.WriteString("	LOCAL pName     :QWORD\n")
+	writer.WriteString("	LOCAL pEnv      :QWORD\n")
+	writer.WriteString("	mov     pName, rcx\n")
+	writer.WriteString("	invoke  build_envp\n")
+	writer.WriteString("	mov     pEnv, rax\n")
+	writer.WriteString(".ge_next:\n")
+	writer.WriteString("	mov     rax, pEnv\n")
+	writer.WriteString("	mov     rdx, [rax]\n")
+	writer.WriteString("	test    rdx, rdx\n")
+	writer.WriteString("	jz      .ge_missing\n")
+	writer.WriteString("	mov     rcx, pName\n")
+	writer.WriteString(".ge_compare:\n")
+	writer.WriteString("	mov     al, BYTE PTR [rcx]\n")
+	writer.WriteString("	test    al, al\n")
+	writer.WriteString("	jz      .ge_name_end\n")
+	// names of environment variables are case insensitive on Windows, so both sides are compared in lowercase
+	writer.WriteString("	cmp     al, 65		; 'A'\n")
+	writer.WriteString("	jb      .ge_fold_entry\n")
+	writer.WriteString("	cmp     al, 90		; 'Z'\n")
+	writer.WriteString("	ja      .ge_fold_entry\n")
+	writer.WriteString("	or      al, 32\n")
+	writer.WriteString(".ge_fold_entry:\n")
+	writer.WriteString("	mov     r8b, BYTE PTR [rdx]\n")
+	writer.WriteString("	cmp     r8b, 65		; 'A'\n")
+	writer.WriteString("	jb      .ge_match\n")
+	writer.WriteString("	cmp     r8b, 90		; 'Z'\n")
+	writer.WriteString("	ja      .ge_match\n")
+	writer.WriteString("	or      r8b, 32\n")
+	writer.WriteString(".ge_match:\n")
+	writer.WriteString("	cmp     al, r8b\n")
+	writer.WriteString("	jne     .ge_skip\n")
+	writer.WriteString("	inc     rcx\n")
+	writer.WriteString("	inc     rdx\n")
+	writer.WriteString("	jmp     .ge_compare\n")
+	writer.WriteString(".ge_name_end:\n")
+	writer.WriteString("	cmp     BYTE PTR [rdx], 61		; '='\n")
+	writer.WriteString("	jne     .ge_skip\n")
+	writer.WriteString("	lea     rax, [rdx+1]\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString(".ge_skip:\n")
+	writer.WriteString("	add     pEnv, 8\n")
+	writer.WriteString("	jmp     .ge_next\n")
+	writer.WriteString(".ge_missing:\n")
+	writer.WriteString("	xor     rax, rax\n")
+	writer.WriteString("	ret\n")
+	writer.WriteString("get_env endp\n")
 	writer.Flush()
 
 	var immediateStringCount int
@@ -210,6 +666,12 @@ func compileMasm(program orth_types.Program, output *os.File) {
 			writer.WriteString("	push rax\n")
 			immediateStringCount++
 		case orth_types.InstructionMem:
+			if region, ok := op.Links["memory_region"]; ok {
+				writer.WriteString(fmt.Sprintf("; push offset %s\n", region.Operator.Operand))
+				writer.WriteString("	mov rax, offset " + embedded_helpers.MangleVarName(region) + "\n")
+				writer.WriteString("	push rax\n")
+				break
+			}
 			writer.WriteString("; push offset mem\n")
 			writer.WriteString("	mov rax, offset mem\n")
 			writer.WriteString("	push rax\n")
@@ -217,6 +679,69 @@ func compileMasm(program orth_types.Program, output *os.File) {
 			writer.WriteString("; put_char\n")
 			writer.WriteString("	pop rcx\n")
 			writer.WriteString("	invoke put_char\n")
+		case orth_types.FunctionFOpen:
+			writer.WriteString("; fopen\n")
+			writer.WriteString("	pop rcx ; path\n")
+			writer.WriteString("	pop rdx ; mode\n")
+			writer.WriteString("	invoke f_open\n")
+			writer.WriteString("	push rax\n")
+		case orth_types.FunctionFRead:
+			writer.WriteString("; fread\n")
+			writer.WriteString("	pop rcx ; handle\n")
+			writer.WriteString("	pop rdx ; buffer\n")
+			writer.WriteString("	pop r8 ; amount of bytes\n")
+			writer.WriteString("	invoke f_read\n")
+			writer.WriteString("	push rax\n")
+		case orth_types.FunctionFWrite:
+			writer.WriteString("; fwrite\n")
+			writer.WriteString("	pop rcx ; handle\n")
+			writer.WriteString("	pop rdx ; buffer\n")
+			writer.WriteString("	pop r8 ; amount of bytes\n")
+			writer.WriteString("	invoke f_write\n")
+			writer.WriteString("	push rax\n")
+		case orth_types.FunctionFClose:
+			writer.WriteString("; fclose\n")
+			writer.WriteString("	pop rcx\n")
+			writer.WriteString("	invoke f_close\n")
+		case orth_types.FunctionFSize:
+			writer.WriteString("; fsize\n")
+			writer.WriteString("	pop rcx\n")
+			writer.WriteString("	invoke f_size\n")
+			writer.WriteString("	push rax\n")
+		case orth_types.FunctionUnlink:
+			writer.WriteString("; unlink\n")
+			writer.WriteString("	pop rcx\n")
+			writer.WriteString("	invoke f_unlink\n")
+			writer.WriteString("	push rax\n")
+		case orth_types.FunctionPutStringFd:
+			writer.WriteString("; puts_fd\n")
+			writer.WriteString("	pop rcx ; handle\n")
+			writer.WriteString("	pop rdx ; string\n")
+			writer.WriteString("	invoke puts_fd\n")
+		case orth_types.FunctionEWrite:
+			writer.WriteString("; ewrite\n")
+			writer.WriteString("	pop rdx\n")
+			writer.WriteString("	mov rcx, 2\n")
+			writer.WriteString("	invoke puts_fd\n")
+		case orth_types.FunctionGetChar:
+			writer.WriteString("; getchar\n")
+			writer.WriteString("	invoke get_char\n")
+			writer.WriteString("	push rax\n")
+		case orth_types.FunctionReadLine:
+			writer.WriteString("; read_line\n")
+			writer.WriteString("	pop rcx ; buffer\n")
+			writer.WriteString("	pop rdx ; capacity\n")
+			writer.WriteString("	invoke read_line\n")
+			writer.WriteString("	push rax\n")
+		case orth_types.FunctionReadInt:
+			writer.WriteString("; read_int\n")
+			writer.WriteString("	invoke read_int\n")
+			writer.WriteString("	push rax\n")
+		case orth_types.FunctionGetEnv:
+			writer.WriteString("; getenv\n")
+			writer.WriteString("	pop rcx\n")
+			writer.WriteString("	invoke get_env\n")
+			writer.WriteString("	push rax\n")
 		case orth_types.FunctionAlloc:
 			writer.WriteString("; alloc\n")
 			writer.WriteString("	pop rax\n")
@@ -246,7 +771,12 @@ func compileMasm(program orth_types.Program, output *os.File) {
 			writer.WriteString("; deref\n")
 			writer.WriteString("	pop rax\n")
 			writer.WriteString("	push rbx\n")
-			writer.WriteString("	mov rbx, [rax]\n")
+			if pointee, ok := op.Links["pointee"]; ok {
+				ptrSize, width := embedded_helpers.VarTypeToPtrSize(pointee.Operator)
+				writer.WriteString(fmt.Sprintf("	%s rbx, %s PTR [rax]\n", signExtendInstruction(width), ptrSize))
+			} else {
+				writer.WriteString("	mov rbx, [rax]\n")
+			}
 			writer.WriteString("	pop rax\n")
 			writer.WriteString("	push rbx\n")
 			writer.WriteString("	mov rbx, rax\n")
@@ -254,14 +784,26 @@ func compileMasm(program orth_types.Program, output *os.File) {
 			writer.WriteString("; load\n")
 			writer.WriteString("	pop rax\n")
 			writer.WriteString("	xor rbx, rbx\n")
-			writer.WriteString("	mov bl, BYTE PTR [rax]\n")
+			if pointee, ok := op.Links["pointee"]; ok {
+				ptrSize, width := embedded_helpers.VarTypeToPtrSize(pointee.Operator)
+				writer.WriteString(fmt.Sprintf("	mov %s, %s PTR [rax]\n", sizedRegister("rbx", width), ptrSize))
+			} else {
+				writer.WriteString("	mov bl, BYTE PTR [rax]\n")
+			}
 			writer.WriteString("	push rbx\n")
 		case orth_types.InstructionStore:
 			writer.WriteString("; store\n")
 			writer.WriteString("	pop rbx ; value to store\n")
 			writer.WriteString("	pop rax ; address of mem\n")
-			writer.WriteString("	mov BYTE PTR [rax], bl\n")
+			if pointee, ok := op.Links["pointee"]; ok {
+				ptrSize, width := embedded_helpers.VarTypeToPtrSize(pointee.Operator)
+				writer.WriteString(fmt.Sprintf("	mov %s PTR [rax], %s\n", ptrSize, sizedRegister("rbx", width)))
+			} else {
+				writer.WriteString("	mov BYTE PTR [rax], bl\n")
+			}
 			writer.WriteString("	xor rax, rax\n")
+		case orth_types.InstructionCast:
+			writer.WriteString(fmt.Sprintf("; cast %s\n", op.Operator.SymbolName))
 		case orth_types.FunctionDumpMem:
 			writer.WriteString("; dump_mem\n")
 			writer.WriteString("	pop rbx\n")
@@ -300,6 +842,15 @@ func compileMasm(program orth_types.Program, output *os.File) {
 			writer.WriteString("	cmp rax, rbx\n")
 			writer.WriteString("	cmove rcx, rdx\n")
 			writer.WriteString("	push rcx\n")
+		case orth_types.InstructionNotEqual:
+			writer.WriteString("; NotEqual\n")
+			writer.WriteString("	mov rdx, 1\n")
+			writer.WriteString("	mov rcx, 0\n")
+			writer.WriteString("	pop rax\n")
+			writer.WriteString("	pop rbx\n")
+			writer.WriteString("	cmp rax, rbx\n")
+			writer.WriteString("	cmovne rcx, rdx\n")
+			writer.WriteString("	push rcx\n")
 		case orth_types.InstructionIf:
 			writer.WriteString("; If\n")
 			writer.WriteString("	pop rax\n")
@@ -329,6 +880,17 @@ func compileMasm(program orth_types.Program, output *os.File) {
 				varType := embedded_helpers.VarTypeToLocalAsmType(variableRawValue.Operator)
 				varName := embedded_helpers.MangleVarName(scopeVariable)
 
+				if scopeVariable.IsArray() {
+					procLocalVariables[varAbsPosition] = struct {
+						Initializer, Decl, Type string
+					}{
+						Type:        varType,
+						Decl:        fmt.Sprintf("	LOCAL %s[%d] :%s\n", varName, scopeVariable.ArrayLength(), varType),
+						Initializer: buildLocalArrayInitializer(scopeVariable),
+					}
+					continue
+				}
+
 				procLocalVariables[varAbsPosition] = struct {
 					Initializer, Decl, Type string
 				}{
@@ -367,10 +929,14 @@ func compileMasm(program orth_types.Program, output *os.File) {
 				fmt.Println("[WARN] `with` instruction detected with more than 0 parameters for proc main, if you are trying to get command line arguments, proceed with `with cli` instead")
 			}
 
-			if lastProcMain && op.Operator.Operand == "cli" {
+			if lastProcMain && strings.HasPrefix(op.Operator.Operand, orth_types.StdCli) {
+				if strings.HasSuffix(op.Operator.Operand, orth_types.StdEnv) {
+					writer.WriteString("; EnvP\n")
+					writer.WriteString("	invoke build_envp\n")
+					writer.WriteString("	push rax	; rax = pointer to envp\n")
+				}
 				writer.WriteString("; ArgC & ArgV\n")
-				writer.WriteString("	invoke GetCommandLineW\n")
-				writer.WriteString("	invoke CommandLineToArgvW, rax, addr nArgc\n")
+				writer.WriteString("	invoke build_argv\n")
 				writer.WriteString("	push rax	; rax = pointer to argv\n")
 				writer.WriteString("	mov  rax, nArgc\n")
 				writer.WriteString("	push rax\n")
@@ -419,7 +985,17 @@ func compileMasm(program orth_types.Program, output *os.File) {
 					}
 				}
 				writer.WriteString("	invoke clear_proc_params\n")
-				if closingMainProc {
+				if closingMainProc && outAmount == 1 {
+					// windows exit codes are 32 bits, negative values are accepted as their unsigned counterpart
+					writer.WriteString("; Exit status\n")
+					writer.WriteString("	mov rax, proc_ret_0\n")
+					writer.WriteString("	mov rcx, 4294967295\n")
+					writer.WriteString("	cmp rax, rcx\n")
+					writer.WriteString("	jg exit_status_out_of_range\n")
+					writer.WriteString("	cmp rax, -2147483648\n")
+					writer.WriteString("	jl exit_status_out_of_range\n")
+					writer.WriteString("	invoke ExitProcess, rax\n")
+				} else if closingMainProc {
 					writer.WriteString("	invoke ExitProcess, 0\n")
 				}
 				writer.WriteString("	ret\n")
@@ -478,6 +1054,75 @@ func compileMasm(program orth_types.Program, output *os.File) {
 			}
 
 			writer.WriteString("	invoke clear_proc_returns\n")
+		case orth_types.InstructionProcAddress:
+			writer.WriteString("; push proc address\n")
+			writer.WriteString(fmt.Sprintf("	lea rax, %s\n", op.Operator.Operand))
+			writer.WriteString("	push rax\n")
+		case orth_types.InstructionCallIndirect:
+			writer.WriteString("; invoke through address\n")
+			writer.WriteString("	pop rax\n")
+
+			var argumentsCount, outParamsCount int
+			for k := range op.Links {
+				switch {
+				case strings.HasPrefix(k, "proc_param_"):
+					argumentsCount++
+				case strings.HasPrefix(k, "proc_out_param_"):
+					outParamsCount++
+				}
+			}
+
+			for i := 0; i < argumentsCount; i++ {
+				writer.WriteString(fmt.Sprintf("	pop proc_arg_%d\n", i))
+			}
+			writer.WriteString("	call rax\n")
+
+			for i := 0; i < outParamsCount; i++ {
+				writer.WriteString(fmt.Sprintf("	push proc_ret_%d\n", i))
+			}
+
+			writer.WriteString("	invoke clear_proc_returns\n")
+		case orth_types.InstructionInvoke:
+			extern := op.Links["extern"]
+			arguments, stackSize := embedded_helpers.MarshalForeignCall(orderedParams(extern, "proc_param_"), embedded_helpers.Win64ABI)
+
+			writer.WriteString("; invoke foreign function\n")
+			for i := range arguments {
+				writer.WriteString(fmt.Sprintf("	pop proc_arg_%d\n", i))
+			}
+			// rbx is preserved by the callee, so the stack can be aligned and restored after the call
+			writer.WriteString("	mov rbx, rsp\n")
+			writer.WriteString("	and rsp, -16\n")
+			writer.WriteString(fmt.Sprintf("	sub rsp, %d\n", stackSize))
+			for i, argument := range arguments {
+				switch {
+				case argument.Register == "":
+					writer.WriteString(fmt.Sprintf("	mov rax, proc_arg_%d\n", i))
+					writer.WriteString(fmt.Sprintf("	mov QWORD PTR [rsp+%d], rax\n", argument.StackOffset))
+				case argument.Type == orth_types.StdF32:
+					writer.WriteString(fmt.Sprintf("	movd %s, DWORD PTR proc_arg_%d\n", argument.Register, i))
+				case argument.Type == orth_types.StdF64:
+					writer.WriteString(fmt.Sprintf("	movq %s, proc_arg_%d\n", argument.Register, i))
+				default:
+					writer.WriteString(fmt.Sprintf("	mov %s, proc_arg_%d\n", argument.Register, i))
+				}
+			}
+			writer.WriteString(fmt.Sprintf("	call %s\n", op.Operator.Operand))
+			writer.WriteString("	mov rsp, rbx\n")
+
+			if returnType, ok := extern.Links["proc_out_param_0"]; ok {
+				switch returnType.Operator.Operand {
+				case orth_types.StdF32:
+					writer.WriteString("	movd eax, xmm0\n")
+				case orth_types.StdF64:
+					writer.WriteString("	movq rax, xmm0\n")
+				}
+				writer.WriteString("	push rax\n")
+			}
+		case orth_types.InstructionAsm:
+			writer.WriteString("; inline assembly\n")
+			writer.WriteString(op.Operator.Operand)
+			writer.WriteString("\n")
 		case orth_types.InstructionDup:
 			writer.WriteString("; Dup\n")
 			writer.WriteString("	pop rax\n")
@@ -507,12 +1152,29 @@ func compileMasm(program orth_types.Program, output *os.File) {
 			writer.WriteString("	test rax, rax\n")
 			endAddress, ok := op.Addresses[orth_types.InstructionEnd]
 			if !ok {
-				log.Fatalln("do wihtout end")
+				panic(fmt.Errorf("do wihtout end"))
 			}
 			writer.WriteString(fmt.Sprintf("	jz .LA%d\n", endAddress))
 		case orth_types.InstructionDrop:
 			writer.WriteString("; Drop\n")
 			writer.WriteString("	pop trash\n")
+		case orth_types.InstructionAssert:
+			message := orth_types.Operand{SymbolName: orth_types.StdSTR, Operand: orth_types.AssertionMessage(op)}
+			strNum, ok := immediateStrings[message]
+			if !ok {
+				immediateStrings[message] = immediateStringCount
+				strNum = immediateStringCount
+			}
+			immediateStringCount++
+			writer.WriteString(fmt.Sprintf("; Assert %s\n", op.Operator.Operand))
+			writer.WriteString("	pop rax\n")
+			writer.WriteString("	test rax, rax\n")
+			writer.WriteString(fmt.Sprintf("	jnz .LAS%d\n", ip))
+			writer.WriteString(fmt.Sprintf("	mov rdx, offset str_%d\n", strNum))
+			writer.WriteString("	mov rcx, 2\n")
+			writer.WriteString("	invoke puts_fd\n")
+			writer.WriteString(fmt.Sprintf("	invoke ExitProcess, %d\n", orth_types.ASSERT_EXIT_CODE))
+			writer.WriteString(fmt.Sprintf(".LAS%d:\n", ip))
 		case orth_types.InstructionExit:
 			writer.WriteString("; Exit program\n")
 			writer.WriteString("	pop rax\n")
@@ -533,6 +1195,49 @@ func compileMasm(program orth_types.Program, output *os.File) {
 				writer.WriteString("	mov rax, offset " + embedded_helpers.MangleVarName(holdingVariable) + "\n")
 				writer.WriteString("	push rax\n")
 			}
+		case orth_types.InstructionIndexLoad:
+			array := op.Links["array"]
+			ptrSize, width := embedded_helpers.VarTypeToPtrSize(array.Links["variable_value"].Operator)
+
+			writer.WriteString("; idx@\n")
+			writer.WriteString("	pop rax ; index\n")
+			writer.WriteString(fmt.Sprintf("	cmp rax, %d\n", array.ArrayLength()))
+			writer.WriteString("	jae array_index_out_of_bounds\n")
+			writer.WriteString(loadArrayAddress(array, "rbx"))
+			writer.WriteString(fmt.Sprintf("	%s rcx, %s PTR [rbx+rax*%d]\n", signExtendInstruction(width), ptrSize, width))
+			writer.WriteString("	push rcx\n")
+		case orth_types.InstructionIndexStore:
+			array := op.Links["array"]
+			ptrSize, width := embedded_helpers.VarTypeToPtrSize(array.Links["variable_value"].Operator)
+
+			writer.WriteString("; idx!\n")
+			writer.WriteString("	pop rax ; index\n")
+			writer.WriteString("	pop rcx ; value to store\n")
+			writer.WriteString(fmt.Sprintf("	cmp rax, %d\n", array.ArrayLength()))
+			writer.WriteString("	jae array_index_out_of_bounds\n")
+			writer.WriteString(loadArrayAddress(array, "rbx"))
+			writer.WriteString(fmt.Sprintf("	mov %s PTR [rbx+rax*%d], %s\n", ptrSize, width, sizedRegister("rcx", width)))
+		case orth_types.FunctionPutI64:
+			writer.WriteString("; puti\n")
+			writer.WriteString("	pop rcx\n")
+			writer.WriteString("	invoke put_number, rcx, 10, 1, 0\n")
+		case orth_types.FunctionPutHex:
+			writer.WriteString("; putx\n")
+			writer.WriteString("	pop rcx\n")
+			writer.WriteString("	invoke put_number, rcx, 16, 0, 0\n")
+		case orth_types.FunctionPutBin:
+			writer.WriteString("; putb\n")
+			writer.WriteString("	pop rcx\n")
+			writer.WriteString("	invoke put_number, rcx, 2, 0, 0\n")
+		case orth_types.FunctionPutU64Pad:
+			writer.WriteString("; putui_pad\n")
+			writer.WriteString("	pop rcx ; value\n")
+			writer.WriteString("	pop r9 ; width\n")
+			writer.WriteString("	invoke put_number, rcx, 10, 0, r9\n")
+		case orth_types.FunctionPutBool:
+			writer.WriteString("; putb_bool\n")
+			writer.WriteString("	pop rcx\n")
+			writer.WriteString("	invoke put_bool\n")
 		case orth_types.FunctionPutString:
 			writer.WriteString("; Print string\n")
 			writer.WriteString("	pop rax\n")
@@ -628,3 +1333,59 @@ func compileMasm(program orth_types.Program, output *os.File) {
 	writer.Flush()
 	orth_debug.LogStep("[CMD] Finished writing assembly")
 }
+
+// loadArrayAddress writes the instruction that moves the base address of an array into `register`
+func loadArrayAddress(array orth_types.Operation, register string) string {
+	if array.Context.Name == embedded_helpers.MainScope {
+		return fmt.Sprintf("	mov %s, offset %s\n", register, embedded_helpers.MangleVarName(array))
+	}
+	return fmt.Sprintf("	lea %s, %s\n", register, embedded_helpers.MangleVarName(array))
+}
+
+// signExtendInstruction returns the instruction that loads a value of `width` bytes into a 64 bit register
+func signExtendInstruction(width int) string {
+	switch width {
+	case 8:
+		return "mov"
+	case 4:
+		return "movsxd"
+	default:
+		return "movsx"
+	}
+}
+
+// sizedRegister returns the lower part of a 64 bit register `rax`, `rbx`, `rcx` or `rdx` for a given width
+func sizedRegister(register string, width int) string {
+	base := register[1:2]
+	switch width {
+	case 1:
+		return base + "l"
+	case 2:
+		return base + "x"
+	case 4:
+		return "e" + base + "x"
+	default:
+		return register
+	}
+}
+
+// buildLocalArrayInitializer zeroes a local array or writes its initial values element by element
+func buildLocalArrayInitializer(array orth_types.Operation) string {
+	builder := strings.Builder{}
+	arrayName := embedded_helpers.MangleVarName(array)
+	arrayValue := array.Links["variable_value"].Operator
+	ptrSize, width := embedded_helpers.VarTypeToPtrSize(arrayValue)
+
+	if arrayValue.Operand == "" {
+		builder.WriteString(fmt.Sprintf("	lea rdi, %s\n", arrayName))
+		builder.WriteString("	xor rax, rax\n")
+		builder.WriteString(fmt.Sprintf("	mov rcx, %d\n", array.ArrayLength()*width))
+		builder.WriteString("	rep stosb\n")
+		return builder.String()
+	}
+
+	for i, value := range strings.Split(arrayValue.Operand, ",") {
+		builder.WriteString(fmt.Sprintf("	mov %s PTR %s[%d], %s\n", ptrSize, arrayName, i*width, value))
+	}
+	return builder.String()
+}
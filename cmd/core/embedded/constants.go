@@ -0,0 +1,221 @@
+package embedded
+
+import (
+	"errors"
+	"fmt"
+	embedded_helpers "orth/cmd/core/embedded/helpers"
+	"orth/cmd/pkg/helpers"
+	"orth/cmd/pkg/helpers/functions"
+	orth_types "orth/cmd/pkg/types"
+	"regexp"
+	"strconv"
+)
+
+// isVisibleFrom checks if something declared in `declared` can be reached from `current`
+func isVisibleFrom(declared, current *orth_types.Context) bool {
+	for c := current; c != nil; c = c.Parent {
+		if c == declared {
+			return true
+		}
+	}
+	return false
+}
+
+// literalAt reads a `type value` pair of a numeric literal without consuming it
+func literalAt(preProgram []orth_types.StringEnum, x int) (orth_types.Operand, bool) {
+	if x+1 >= len(preProgram) {
+		return orth_types.Operand{}, false
+	}
+	literal := orth_types.Operand{
+		SymbolName: preProgram[x].Content.Token,
+		Operand:    preProgram[x+1].Content.Token,
+	}
+	if helpers.IsInt(literal) {
+		return literal, true
+	}
+	if _, err := strconv.ParseFloat(literal.Operand, 64); err == nil && helpers.IsFloat(literal) {
+		return literal, true
+	}
+	return orth_types.Operand{}, false
+}
+
+// sizeOfAt reads `sizeof <type>` without consuming it and returns the width of the type in bytes
+func sizeOfAt(preProgram []orth_types.StringEnum, x int) (orth_types.Operand, int, error) {
+	if x+1 >= len(preProgram) {
+		return orth_types.Operand{}, 0, fmt.Errorf("missing type for %q", orth_types.StdSizeOf)
+	}
+	sizedType, consumed := preProgram[x+1].Content.Token, 2
+	if sizedType == orth_types.StdPtr && x+2 < len(preProgram) {
+		sizedType, consumed = orth_types.PointerType(preProgram[x+2].Content.Token), 3
+	}
+	if !orth_types.IsValidTypeSybl(sizedType) {
+		return orth_types.Operand{}, 0, fmt.Errorf("%q is not a valid type for %q", sizedType, orth_types.StdSizeOf)
+	}
+
+	var width int
+	switch {
+	// strings and addresses are pointers
+	case sizedType == orth_types.StdSTR || sizedType == orth_types.StdAddress:
+		width = 8
+	case orth_types.IsPointerType(sizedType) || helpers.IsNumeric(orth_types.Operand{SymbolName: sizedType, Operand: "0"}):
+		_, width = embedded_helpers.VarTypeToPtrSize(orth_types.Operand{SymbolName: sizedType})
+	default:
+		return orth_types.Operand{}, 0, fmt.Errorf("values of type %q have no size in memory", sizedType)
+	}
+	return orth_types.Operand{SymbolName: orth_types.StdINT, Operand: fmt.Sprint(width)}, consumed, nil
+}
+
+// evaluateConstantOperation applies a binary operator on two values known at compile time
+func evaluateConstantOperation(operator string, left, right orth_types.Operand) (result orth_types.Operand, err error) {
+	// the arithmetic helpers panic with an error on values they can not operate on
+	defer func() {
+		if r := recover(); r != nil {
+			recovered, ok := r.(error)
+			if !ok {
+				panic(r)
+			}
+			result, err = orth_types.Operand{}, recovered
+		}
+	}()
+
+	if helpers.IsFloat(left) != helpers.IsFloat(right) {
+		return orth_types.Operand{}, fmt.Errorf("can not apply %q on %q and %q", operator, left.SymbolName, right.SymbolName)
+	}
+	isFloat := helpers.IsFloat(left)
+
+	superType := functions.IntSupersetOfSlice(left, right)
+	if isFloat {
+		superType = functions.FloatSupersetOfSlice(left, right)
+	}
+
+	switch operator {
+	case orth_types.StdDiv:
+		fallthrough
+	case orth_types.StdMod:
+		if divisor, _ := strconv.ParseFloat(right.Operand, 64); divisor == 0 {
+			return orth_types.Operand{}, errors.New("division by zero")
+		}
+	case orth_types.StdLeftShift:
+		fallthrough
+	case orth_types.StdRightShift:
+		fallthrough
+	case orth_types.StdLogicalAnd:
+		fallthrough
+	case orth_types.StdLogicalOr:
+		if isFloat {
+			return orth_types.Operand{}, fmt.Errorf("%q can only be applied on integers", operator)
+		}
+	}
+
+	switch operator {
+	case orth_types.StdPlus:
+		if isFloat {
+			return functions.SumFloats(superType, left, right), nil
+		}
+		return functions.SumIntegers(superType, left, right), nil
+	case orth_types.StdMinus:
+		if isFloat {
+			return functions.SubFloats(superType, left, right), nil
+		}
+		return functions.SubIntegers(superType, left, right), nil
+	case orth_types.StdMult:
+		if isFloat {
+			return functions.MultplyFloats(superType, left, right), nil
+		}
+		return functions.MultplyIntegers(superType, left, right), nil
+	case orth_types.StdDiv:
+		if isFloat {
+			return functions.DivideFloats(superType, left, right), nil
+		}
+		return functions.DivideIntegers(superType, left, right), nil
+	case orth_types.StdMod:
+		if isFloat {
+			return functions.ModFloats(superType, left, right), nil
+		}
+		return functions.ModIntegers(superType, left, right), nil
+	case orth_types.StdLeftShift:
+		return functions.LeftShiftInt(superType, right, left), nil
+	case orth_types.StdRightShift:
+		return functions.RightShiftInt(superType, right, left), nil
+	case orth_types.StdLogicalAnd:
+		return functions.BitwiseAnd(superType, left, right), nil
+	case orth_types.StdLogicalOr:
+		return functions.BitwiseOr(superType, left, right), nil
+	}
+	return orth_types.Operand{}, fmt.Errorf("%q can not be evaluated at compile time", operator)
+}
+
+// isConstantOperator checks if a token is an operator allowed on constant expressions
+func isConstantOperator(token string) bool {
+	switch token {
+	case orth_types.StdPlus, orth_types.StdMinus, orth_types.StdMult, orth_types.StdDiv, orth_types.StdMod,
+		orth_types.StdLeftShift, orth_types.StdRightShift, orth_types.StdLogicalAnd, orth_types.StdLogicalOr:
+		return true
+	}
+	return false
+}
+
+// grabConstantExpression evaluates var/const initializers written as stack expressions on the same line
+// of the declaration, like `const AREA = i 30 i 28 *` or `const STRIDE = sizeof i32 ROWS *`.
+// Initializers made of a single literal are left for grabVariableDefinition and report false
+func grabConstantExpression(preProgram []orth_types.StringEnum, i int, constants map[string]orth_types.Operation, context *orth_types.Context) (string, string, bool, error) {
+	start := definitionStart(preProgram, i)
+	line := preProgram[i].Index
+
+	stack := make([]orth_types.Operand, 0)
+	end := -1
+	var result orth_types.Operand
+
+	for x := start; x < len(preProgram) && preProgram[x].Index == line; {
+		token := preProgram[x].Content.Token
+		next := x + 1
+		isOperator := false
+		isLiteral := false
+
+		if literal, ok := literalAt(preProgram, x); ok {
+			stack = append(stack, literal)
+			next = x + 2
+			isLiteral = true
+		} else if constant, ok := constants[token]; ok && isVisibleFrom(constant.Context, context) && !constant.IsArray() {
+			stack = append(stack, constant.Links["variable_value"].Operator)
+		} else if token == orth_types.StdSizeOf {
+			size, consumed, err := sizeOfAt(preProgram, x)
+			if err != nil {
+				return "", "", false, err
+			}
+			stack = append(stack, size)
+			next = x + consumed
+		} else if isConstantOperator(token) && len(stack) >= 2 {
+			right := stack[len(stack)-1]
+			left := stack[len(stack)-2]
+			value, err := evaluateConstantOperation(token, left, right)
+			if err != nil {
+				return "", "", false, err
+			}
+			stack = append(stack[:len(stack)-2], value)
+			isOperator = true
+		} else {
+			break
+		}
+
+		// a single literal is not an expression, but a single constant or `sizeof` is
+		if len(stack) == 1 && (isOperator || (x == start && !isLiteral)) {
+			end = next
+			result = stack[0]
+		}
+		x = next
+	}
+
+	if end < 0 {
+		return "", "", false, nil
+	}
+	if regexp.MustCompile(`[^\w]`).Match([]byte(orth_types.UnscopedName(preProgram[i+1].Content.Token))) {
+		return "", "", false, errors.New("name has invalid characters in it's composition")
+	}
+	for x := start; x < end; x++ {
+		preProgram[x].Content.ValidPos = true
+	}
+	preProgram[i+1].Content.ValidPos = true
+
+	return result.Operand, result.SymbolName, true, nil
+}
@@ -0,0 +1,96 @@
+package embedded
+
+import (
+	"fmt"
+	"html"
+	orth_types "orth/cmd/pkg/types"
+	"strings"
+)
+
+// CollectDocumentation lists the procs, consts, vars and enums of `tokenFiles` that are preceded by a `##` comment.
+// They are read from the source of each file, so the names of modules are the ones that were written
+func CollectDocumentation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_types.StringEnum]]) []orth_types.Documentation {
+	docs := make([]orth_types.Documentation, 0)
+	for _, file := range tokenFiles {
+		tokens := *file.Source.Slice
+		for i, v := range tokens {
+			if v.Doc == "" {
+				continue
+			}
+			kind := i
+			if v.Content.Token == orth_types.StdPub && v.Content.Kind == orth_types.TokenKeyword {
+				kind++
+			}
+			if kind+1 >= len(tokens) {
+				continue
+			}
+			switch tokens[kind].Content.Token {
+			case orth_types.StdProc, orth_types.StdConst, orth_types.StdVar, orth_types.StdEnum:
+			default:
+				continue
+			}
+
+			// the declaration is what is written on its line, up to the `in` of a proc
+			declaration := make([]string, 0)
+			for _, token := range tokens[i:] {
+				if token.File != v.File || token.Index != v.Index {
+					break
+				}
+				declaration = append(declaration, token.Content.Token)
+				if token.Content.Token == orth_types.StdIn && tokens[kind].Content.Token == orth_types.StdProc {
+					break
+				}
+			}
+
+			docs = append(docs, orth_types.Documentation{
+				Kind:        tokens[kind].Content.Token,
+				Name:        tokens[kind+1].Content.Token,
+				Declaration: strings.Join(declaration, " "),
+				Text:        v.Doc,
+				File:        v.File,
+				Line:        v.Index,
+			})
+		}
+	}
+	return docs
+}
+
+// RenderDocumentation writes `docs` as a reference grouped by file, either in markdown ("md") or "html"
+func RenderDocumentation(docs []orth_types.Documentation, format string) (string, error) {
+	var heading, entry, footer string
+	escape := func(s string) string { return s }
+	switch format {
+	case "md":
+		heading = "## %s\n\n"
+		entry = "### %s `%s`\n\n```orth\n%s\n```\n\n%s\n\n"
+	case "html":
+		heading = "<h2>%s</h2>\n"
+		entry = "<h3>%s <code>%s</code></h3>\n<pre><code>%s</code></pre>\n<p>%s</p>\n"
+		footer = "</body>\n</html>\n"
+		escape = html.EscapeString
+	default:
+		return "", fmt.Errorf("unknown documentation format %q, expected md or html", format)
+	}
+
+	var reference strings.Builder
+	if format == "html" {
+		reference.WriteString("<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>API reference</title></head>\n<body>\n<h1>API reference</h1>\n")
+	} else {
+		reference.WriteString("# API reference\n\n")
+	}
+
+	file := ""
+	for _, doc := range docs {
+		if doc.File != file {
+			file = doc.File
+			reference.WriteString(fmt.Sprintf(heading, escape(file)))
+		}
+		text := escape(doc.Text)
+		if format == "html" {
+			text = strings.ReplaceAll(text, "\n", "<br>\n")
+		}
+		reference.WriteString(fmt.Sprintf(entry, doc.Kind, escape(doc.Name), escape(doc.Declaration), text))
+	}
+	reference.WriteString(footer)
+	return reference.String(), nil
+}
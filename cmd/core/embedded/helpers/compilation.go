@@ -44,6 +44,9 @@ func CleanUp() {
 }
 
 func VarTypeToLocalAsmType(operand orth_types.Operand) string {
+	if orth_types.IsPointerType(operand.SymbolName) {
+		return "QWORD"
+	}
 	switch operand.SymbolName {
 	case orth_types.StdSTR:
 		panic("string not supported for local scopes")
@@ -66,14 +69,15 @@ func VarTypeToLocalAsmType(operand orth_types.Operand) string {
 	case orth_types.StdF64:
 		return "REAL8"
 	default:
-		fmt.Fprintf(os.Stderr, "ivalid type od %q\n", operand.SymbolName)
-		os.Exit(1)
-		return ""
+		panic(fmt.Errorf("ivalid type od %q", operand.SymbolName))
 	}
 }
 
 func VarTypeToAsmType(operand orth_types.Operand) string {
 	var asmTypeInstruction string
+	if orth_types.IsPointerType(operand.SymbolName) {
+		return "dq"
+	}
 	switch operand.SymbolName {
 	case orth_types.StdSTR:
 		asmTypeInstruction = "db"
@@ -96,8 +100,7 @@ func VarTypeToAsmType(operand orth_types.Operand) string {
 	case orth_types.StdF64:
 		asmTypeInstruction = "real8"
 	default:
-		fmt.Fprintf(os.Stderr, "ivalid type of %q\n", operand.SymbolName)
-		os.Exit(1)
+		panic(fmt.Errorf("ivalid type of %q", operand.SymbolName))
 	}
 	return asmTypeInstruction
 }
@@ -145,6 +148,8 @@ func MangleVarName(o orth_types.Operation) string {
 		memType = "Var"
 	} else if o.Instruction == orth_types.InstructionConst || o.Operator.SymbolName == orth_types.StdConst {
 		memType = "Const"
+	} else if o.Instruction == orth_types.InstructionMemory {
+		memType = "Memory"
 	} else {
 		panic(fmt.Errorf("invalid operation on type %d", o.Instruction))
 	}
@@ -152,8 +157,32 @@ func MangleVarName(o orth_types.Operation) string {
 	return fmt.Sprintf("%s@%s@%s", o.Context.Name, memType, o.Operator.Operand)
 }
 
+// VarTypeToPtrSize returns the size used by `PTR` instructions and the width in bytes for a type
+func VarTypeToPtrSize(operand orth_types.Operand) (string, int) {
+	localType := VarTypeToLocalAsmType(operand)
+	switch localType {
+	case "REAL4":
+		localType = "DWORD"
+	case "REAL8":
+		localType = "QWORD"
+	}
+	return localType, int(AsmVariablePriority[localType])
+}
+
 func BuildVarDataSeg(variable orth_types.Operation) string {
 	variableValue := variable.Links["variable_value"].Operator
+	if variable.IsArray() && variableValue.Operand == "" {
+		// a read-only segment can not be left uninitialized
+		initializer := "?"
+		if variable.Instruction == orth_types.InstructionConst {
+			initializer = "0"
+		}
+		return fmt.Sprintf("%s %s %d dup(%s)",
+			MangleVarName(variable),
+			VarTypeToAsmType(variableValue),
+			variable.ArrayLength(),
+			initializer)
+	}
 	return fmt.Sprintf("%s %s %s",
 		MangleVarName(variable),
 		VarTypeToAsmType(variableValue),
@@ -0,0 +1,55 @@
+package embedded_helpers
+
+import (
+	orth_types "orth/cmd/pkg/types"
+)
+
+// CallingConvention describes how the arguments of a foreign function are passed,
+// the n-th argument using the n-th register of its kind
+type CallingConvention struct {
+	IntRegisters   []string
+	FloatRegisters []string
+	ShadowSpace    int
+}
+
+var Win64ABI = CallingConvention{
+	IntRegisters:   []string{"rcx", "rdx", "r8", "r9"},
+	FloatRegisters: []string{"xmm0", "xmm1", "xmm2", "xmm3"},
+	ShadowSpace:    32,
+}
+
+// ForeignArgument is where a single argument of a foreign call is placed,
+// either a register or an offset from the stack pointer
+type ForeignArgument struct {
+	Type        string
+	Register    string
+	StackOffset int
+}
+
+// MarshalForeignCall assigns every argument type to a register or to a slot on the stack,
+// returning the amount of bytes that must be reserved on the stack for the call
+func MarshalForeignCall(argTypes []string, convention CallingConvention) ([]ForeignArgument, int) {
+	arguments := make([]ForeignArgument, len(argTypes))
+	stackOffset := convention.ShadowSpace
+
+	for i, argType := range argTypes {
+		arguments[i].Type = argType
+		isFloat := orth_types.GlobalTypes[orth_types.FLOATS][argType] != ""
+
+		switch {
+		case isFloat && i < len(convention.FloatRegisters):
+			arguments[i].Register = convention.FloatRegisters[i]
+		case !isFloat && i < len(convention.IntRegisters):
+			arguments[i].Register = convention.IntRegisters[i]
+		default:
+			arguments[i].StackOffset = stackOffset
+			stackOffset += 8
+		}
+	}
+
+	// the stack must stay aligned to 16 bytes on the call
+	if stackOffset%16 != 0 {
+		stackOffset += 8
+	}
+	return arguments, stackOffset
+}
@@ -3,9 +3,9 @@ package embedded_helpers
 import (
 	"errors"
 	"fmt"
-	"log"
-	"os"
+	"strings"
 
+	"orth/cmd/core/orth_debug"
 	orth_types "orth/cmd/pkg/types"
 
 	"golang.org/x/exp/constraints"
@@ -29,17 +29,49 @@ func PopLast[T any](stack *[]T) T {
 	return item
 }
 
-func HandleOperationDo(stack *[]RefStackItem, program *orth_types.Program, operationIndex uint) {
+// blockName is the keyword of a block instruction as written on the source code
+func blockName(instruction orth_types.Instruction) string {
+	return strings.ToLower(orth_types.InstructionToStr(instruction))
+}
+
+// unbalancedBlock builds the error of the operation at `operationIndex`, which can not close the block `opened`
+func unbalancedBlock(program *orth_types.Program, operationIndex uint, opened RefStackItem, reason string) error {
+	operation := program.Operations[operationIndex]
+	opener := program.Operations[opened.AbsPosition]
+	return orth_debug.Block(blockName(operation.Instruction), reason, operation.Location, blockName(opener.Instruction), opener.Location)
+}
+
+// noOpenBlock builds the error of the operation at `operationIndex` found when there is no block open
+func noOpenBlock(program *orth_types.Program, operationIndex uint) error {
+	operation := program.Operations[operationIndex]
+	return orth_debug.Block(blockName(operation.Instruction), "there is no block open", operation.Location, "", orth_types.Location{})
+}
+
+// UnclosedBlock builds the error of a block that is still open at the end of the program
+func UnclosedBlock(program *orth_types.Program, opened RefStackItem) error {
+	opener := program.Operations[opened.AbsPosition]
+	return orth_debug.Block(blockName(opener.Instruction), fmt.Sprintf("it is never closed by %q", orth_types.StdEND), opener.Location, "", orth_types.Location{})
+}
+
+func HandleOperationDo(stack *[]RefStackItem, program *orth_types.Program, operationIndex uint) error {
+	if len(*stack) == 0 {
+		return noOpenBlock(program, operationIndex)
+	}
 	lastStackItem := PopLast(stack)
 	switch lastStackItem.Instruction {
 	case orth_types.InstructionWhile:
 		program.Operations[operationIndex].Addresses[orth_types.InstructionWhile] = int(lastStackItem.AbsPosition)
 	default:
-		log.Fatalln("non logical block found before 'DO' operation, syntax error")
+		*stack = append(*stack, lastStackItem)
+		return unbalancedBlock(program, operationIndex, lastStackItem, fmt.Sprintf("it can only follow a %q condition", orth_types.StdWhile))
 	}
+	return nil
 }
 
-func HandleOperationEnd(stack *[]RefStackItem, program *orth_types.Program, currentOperationIndex uint) {
+func HandleOperationEnd(stack *[]RefStackItem, program *orth_types.Program, currentOperationIndex uint) error {
+	if len(*stack) == 0 {
+		return noOpenBlock(program, currentOperationIndex)
+	}
 	lastStackItem := PopLast(stack)
 	switch lastStackItem.Instruction {
 	case orth_types.InstructionIf:
@@ -61,16 +93,26 @@ func HandleOperationEnd(stack *[]RefStackItem, program *orth_types.Program, curr
 
 		program.Operations[currentOperationIndex].Addresses[orth_types.InstructionWhile] = int(whileAddress)
 		program.Operations[doOperation.AbsPosition].Addresses[orth_types.InstructionEnd] = int(currentOperationIndex)
+	default:
+		return unbalancedBlock(program, currentOperationIndex, lastStackItem, fmt.Sprintf("a %q loop is missing its %q", orth_types.StdWhile, orth_types.StdDo))
 	}
+	return nil
 }
 
-func HandleOperationElse(stack *[]RefStackItem, program *orth_types.Program, operationIndex uint) {
+func HandleOperationElse(stack *[]RefStackItem, program *orth_types.Program, operationIndex uint) error {
+	if len(*stack) == 0 {
+		return noOpenBlock(program, operationIndex)
+	}
 	lastStackItem := PopLast(stack)
 	switch lastStackItem.Instruction {
 	case orth_types.InstructionIf:
 		ifOperation := lastStackItem
 		program.Operations[ifOperation.AbsPosition].Addresses[orth_types.InstructionElse] = int(operationIndex)
+	default:
+		*stack = append(*stack, lastStackItem)
+		return unbalancedBlock(program, operationIndex, lastStackItem, fmt.Sprintf("it can only follow an %q block", orth_types.StdIf))
 	}
+	return nil
 }
 
 func GetVariableContext(variable orth_types.ContextDeclaration, context *orth_types.Context) (string, error) {
@@ -107,15 +149,14 @@ func OperationIsVariableLike(operation orth_types.Operation, program *orth_types
 	return "", err
 }
 
-func ProduceOperator[TOperand constraints.Float | constraints.Integer](param1, param2 TOperand, instruction orth_types.Instruction) (string, bool) {
+func ProduceOperator[TOperand constraints.Float | constraints.Integer](param1, param2 TOperand, instruction orth_types.Instruction) (operand string, ok bool) {
 	defer func() {
+		// operations that can not be folded, like a division by zero, are left for the runtime
 		if err := recover(); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			operand, ok = "", false
 		}
 	}()
 
-	operand := ""
 	if instruction == orth_types.InstructionMult {
 		operand = fmt.Sprint(param1 * param2)
 	} else if instruction == orth_types.InstructionSum {
@@ -1,18 +1,24 @@
 package embedded
 
 import (
+	"errors"
 	"fmt"
 	embedded_helpers "orth/cmd/core/embedded/helpers"
 	"orth/cmd/core/orth_debug"
+	"orth/cmd/pkg/helpers/functions"
 	orth_types "orth/cmd/pkg/types"
-	"os"
 	"regexp"
+	"strconv"
+	"strings"
 )
 
 // CrossReferenceBlocks loops over a program and define all inter references
-// needed for execution. Ex: if-else-do blocks
-func CrossReferenceBlocks(program orth_types.Program) (orth_types.Program, error) {
+// needed for execution. Ex: if-else-do blocks. Every error found is added to `program.Error`
+func CrossReferenceBlocks(program orth_types.Program) orth_types.Program {
 	stack := make([]embedded_helpers.RefStackItem, 0, len(program.Operations))
+	report := func(operation orth_types.Operation, err error) {
+		program.Error = append(program.Error, orth_debug.Locate(operation.Location, err))
+	}
 
 	for operationIndex, operation := range program.Operations {
 		switch operation.Instruction {
@@ -24,14 +30,22 @@ func CrossReferenceBlocks(program orth_types.Program) (orth_types.Program, error
 			if operation.Context.Name == embedded_helpers.MainScope {
 				program.Constants = append(program.Constants, operation)
 			}
+		case orth_types.InstructionMemory:
+			size := program.Operations[operationIndex].Links["memory_size"].Operator.Operand
+			if operation.Operator.Operand == orth_types.StdMem {
+				memSize, _ := strconv.ParseUint(size, 10, 0)
+				program.MemSize = uint(memSize)
+				continue
+			}
+			program.MemoryRegions = append(program.MemoryRegions, operation)
 		case orth_types.InstructionHold:
 			variable, err := operation.Context.GetVaraible(operation.Operator.Operand, &program)
 			if err != nil {
-				fmt.Fprint(os.Stderr, orth_debug.BuildErrorMessage(
+				report(operation, orth_debug.BuildErrorMessage(
 					orth_debug.ORTH_ERR_04,
 					orth_types.InstructionToStr(orth_types.InstructionHold),
 					err))
-				os.Exit(1)
+				continue
 			}
 			if program.Operations[operationIndex].Links == nil {
 				program.Operations[operationIndex].Links = make(map[string]orth_types.Operation)
@@ -44,6 +58,54 @@ func CrossReferenceBlocks(program orth_types.Program) (orth_types.Program, error
 			} else {
 				program.Operations[operationIndex].Links["hold_local"] = *variable
 			}
+		case orth_types.InstructionIndexLoad:
+			fallthrough
+		case orth_types.InstructionIndexStore:
+			variable, err := operation.Context.GetVaraible(operation.Operator.Operand, &program)
+			if err != nil {
+				report(operation, orth_debug.BuildErrorMessage(
+					orth_debug.ORTH_ERR_04,
+					orth_types.InstructionToStr(operation.Instruction),
+					err))
+				continue
+			}
+			if !variable.IsArray() {
+				report(operation, orth_debug.BuildErrorMessage(
+					orth_debug.ORTH_ERR_04,
+					orth_types.InstructionToStr(operation.Instruction),
+					fmt.Sprintf("%q is not an array\n", operation.Operator.Operand)))
+				continue
+			}
+			if operation.Instruction == orth_types.InstructionIndexStore && variable.Instruction == orth_types.InstructionConst {
+				report(operation, orth_debug.BuildErrorMessage(
+					orth_debug.ORTH_ERR_04,
+					orth_types.InstructionToStr(operation.Instruction),
+					fmt.Sprintf("%q is a constant and can not be written\n", operation.Operator.Operand)))
+				continue
+			}
+			program.Operations[operationIndex].Links["array"] = *variable
+		case orth_types.InstructionInvoke:
+			for _, extern := range program.Operations {
+				if extern.Instruction == orth_types.InstructionExtern && extern.Operator.Operand == operation.Operator.Operand {
+					program.Operations[operationIndex].Links["extern"] = extern
+					break
+				}
+			}
+			if _, ok := program.Operations[operationIndex].Links["extern"]; !ok {
+				report(operation, orth_debug.BuildErrorMessage(
+					orth_debug.ORTH_ERR_04,
+					orth_types.InstructionToStr(operation.Instruction),
+					fmt.Sprintf("foreign function %q was not declared with %q\n", operation.Operator.Operand, orth_types.StdExtern)))
+				continue
+			}
+		case orth_types.InstructionProcAddress:
+			if _, err := program.FindProc(operation); err != nil {
+				report(operation, orth_debug.BuildErrorMessage(
+					orth_debug.ORTH_ERR_04,
+					orth_types.InstructionToStr(operation.Instruction),
+					fmt.Sprintf("%s\n", err)))
+				continue
+			}
 		case orth_types.InstructionWhile:
 			fallthrough
 		case orth_types.InstructionIf:
@@ -54,28 +116,44 @@ func CrossReferenceBlocks(program orth_types.Program) (orth_types.Program, error
 				Instruction: operation.Instruction,
 			})
 		case orth_types.InstructionDo:
-			embedded_helpers.HandleOperationDo(&stack, &program, uint(operationIndex))
+			// a misplaced `do` still opens its block, so the `end` closing it is not reported as well
+			if err := embedded_helpers.HandleOperationDo(&stack, &program, uint(operationIndex)); err != nil {
+				report(operation, err)
+			}
 			stack = append(stack, embedded_helpers.RefStackItem{
 				AbsPosition: uint(operationIndex),
 				Instruction: operation.Instruction,
 			})
 		case orth_types.InstructionElse:
-			embedded_helpers.HandleOperationElse(&stack, &program, uint(operationIndex))
+			// a misplaced `else` still opens its block, so the `end` closing it is not reported as well
+			if err := embedded_helpers.HandleOperationElse(&stack, &program, uint(operationIndex)); err != nil {
+				report(operation, err)
+			}
 			stack = append(stack, embedded_helpers.RefStackItem{
 				AbsPosition: uint(operationIndex),
 				Instruction: operation.Instruction,
 			})
 		case orth_types.InstructionEnd:
-			embedded_helpers.HandleOperationEnd(&stack, &program, uint(operationIndex))
+			if err := embedded_helpers.HandleOperationEnd(&stack, &program, uint(operationIndex)); err != nil {
+				report(operation, err)
+			}
 		}
 	}
 
-	return program, nil
+	for _, opened := range stack {
+		report(program.Operations[opened.AbsPosition], embedded_helpers.UnclosedBlock(&program, opened))
+	}
+
+	return program
 }
 
 // ParseTokenAsOperation parses an slice of pre-instructions into a runnable program
 func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_types.StringEnum]], parsedOperation chan<- orth_types.Pair[orth_types.Operation, error]) {
 	procNames := make(map[string]int)
+	memoryRegions := make(map[string]orth_types.Operation)
+	enums := make(map[string]orth_types.Operation)
+	constants := make(map[string]orth_types.Operation)
+	externs := make(map[string]bool)
 
 	context := &orth_types.Context{
 		Name:          embedded_helpers.MainScope,
@@ -85,6 +163,27 @@ func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_
 		InnerContexts: make([]*orth_types.Context, 0),
 	}
 
+	// the token being parsed, which is the location of the operations parsed out of it
+	var current orth_types.StringEnum
+	emit := func(op orth_types.Operation) {
+		op.Location = current.Location()
+		parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
+			Left:  op,
+			Right: nil,
+		}
+	}
+	fail := func(err error) {
+		parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
+			Left:  orth_types.Operation{},
+			Right: err,
+		}
+	}
+	// report sends an error and skips the rest of the statement, so the parsing goes on and reports every error of the program
+	report := func(preProgram []orth_types.StringEnum, i int, err error) {
+		fail(err)
+		skipStatement(preProgram, i)
+	}
+
 	var globalInstructionIndex uint = 0
 	for fIndex, file := range tokenFiles {
 		for i, v := range *file.CodeBlock.Slice {
@@ -93,6 +192,7 @@ func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_
 			if v.Content.ValidPos {
 				continue
 			}
+			current = v
 			switch v.Content.Token {
 			case orth_types.ADDR:
 				fallthrough
@@ -117,71 +217,53 @@ func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_
 			case orth_types.StdBOOL:
 				preProgram[i+1].Content.ValidPos = true
 				ins := parseToken(v.Content.Token, preProgram[i+1].Content.Token, context, orth_types.InstructionPush)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdSTR:
 				preProgram[i+1].Content.ValidPos = true
 				ins := parseToken(orth_types.StdSTR, preProgram[i+1].Content.Token[1:len(preProgram[i+1].Content.Token)-1], context, orth_types.InstructionPushStr)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdPlus:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionSum)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdMinus:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionMinus)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdMult:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionMult)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdDiv:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionDiv)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdPutUint:
 				ins := parseToken(orth_types.StdVOID, "", context, orth_types.FunctionPutU64)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
+			case orth_types.StdPutUintPad:
+				ins := parseToken(orth_types.StdVOID, "", context, orth_types.FunctionPutU64Pad)
+				emit(ins)
+			case orth_types.StdPutInt:
+				ins := parseToken(orth_types.StdVOID, "", context, orth_types.FunctionPutI64)
+				emit(ins)
+			case orth_types.StdPutHex:
+				ins := parseToken(orth_types.StdVOID, "", context, orth_types.FunctionPutHex)
+				emit(ins)
+			case orth_types.StdPutBin:
+				ins := parseToken(orth_types.StdVOID, "", context, orth_types.FunctionPutBin)
+				emit(ins)
+			case orth_types.StdPutBool:
+				ins := parseToken(orth_types.StdVOID, "", context, orth_types.FunctionPutBool)
+				emit(ins)
 			case orth_types.StdEquals:
 				ins := parseToken(orth_types.StdBOOL, "", context, orth_types.InstructionEqual)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdNotEquals:
 				ins := parseToken(orth_types.StdBOOL, "", context, orth_types.InstructionNotEqual)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdLowerThan:
 				ins := parseToken(orth_types.StdBOOL, "", context, orth_types.InstructionLt)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdGreaterThan:
 				ins := parseToken(orth_types.StdBOOL, "", context, orth_types.InstructionGt)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdIf:
 				newContext := orth_types.Context{
 					Name:          fmt.Sprintf("c?_if_%d$", len(context.InnerContexts)),
@@ -194,10 +276,7 @@ func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_
 				context = &newContext
 
 				ins := parseToken(orth_types.StdBOOL, "", context, orth_types.InstructionIf)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdElse:
 				// context is an "if" block that must not have a "else" block as a child, they should be siblings
 				// so append to context.Parent.InnerContext
@@ -213,85 +292,48 @@ func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_
 				context = &newContext
 
 				ins := parseToken(orth_types.StdBOOL, "", context, orth_types.InstructionElse)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdEND:
 				if context.Parent != nil {
 					context = context.Parent
 				}
 				ins := parseToken(orth_types.StdEND, "", context, orth_types.InstructionEnd)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdPutStr:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionPutString)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdOver:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionOver)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.Std2Dup:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionTwoDup)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdDup:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionDup)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdWhile:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionWhile)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdLeftShift:
 				ins := parseToken(orth_types.StdBitwise, "", context, orth_types.InstructionLShift)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdRightShift:
 				ins := parseToken(orth_types.StdBitwise, "", context, orth_types.InstructionRShift)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdLogicalAnd:
 				ins := parseToken(orth_types.StdBitwise, "", context, orth_types.InstructionLAnd)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdLogicalOr:
 				ins := parseToken(orth_types.StdBitwise, "", context, orth_types.InstructionLOr)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdProc:
 				preProgram[i+1].Content.ValidPos = true
 				pName := preProgram[i+1].Content.Token
 
+				// the body of a redeclared proc is still parsed as one, so it does not report errors of its own
 				procNames[pName]++
 				if procNames[pName] != 1 {
-					parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-						Left:  orth_types.Operation{},
-						Right: orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_02, "PROC", pName, file.Name, v.Index, v.Content.Index),
-					}
-					close(parsedOperation)
-					return
+					fail(orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_02, "PROC", pName, v.File, v.Index, v.Content.Index)))
 				}
 
 				newContext := orth_types.Context{
@@ -305,16 +347,43 @@ func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_
 				context = &newContext
 
 				ins := parseToken(orth_types.StdProc, pName, context, orth_types.InstructionProc)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
+				emit(ins)
+			case orth_types.StdWith:
+				// only `proc main with cli [env]` or `proc main with <ins>`, both optionally followed by `out <outs>`, are accepted
+				if i < 2 || preProgram[i-2].Content.Token != orth_types.StdProc || preProgram[i-1].Content.Token != "main" || i+1 >= len(preProgram) {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_27, orth_types.StdWith, "expected `proc main with cli` optionally followed by `env`, or `proc main with 0 out <0 or 1>`", v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				preProgram[i+1].Content.ValidPos = true
+				next := i + 2
+				if preProgram[i+1].Content.Token == orth_types.StdCli {
+					operand := orth_types.StdCli
+					if next < len(preProgram) && preProgram[next].Content.Token == orth_types.StdEnv {
+						preProgram[next].Content.ValidPos = true
+						operand = fmt.Sprintf("%s %s", orth_types.StdCli, orth_types.StdEnv)
+						next++
+					}
+					ins := parseToken(orth_types.StdWith, operand, context, orth_types.InstructionWith)
+					emit(ins)
+				} else if amount, err := strconv.Atoi(preProgram[i+1].Content.Token); err != nil || amount != 0 {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_27, orth_types.StdWith, fmt.Sprintf("`main` takes no values, found %q", preProgram[i+1].Content.Token), v.File, v.Index, v.Content.Index)))
+					continue
+				}
+
+				if next >= len(preProgram) || preProgram[next].Content.Token != orth_types.StdOut {
+					continue
+				}
+				ins, err := grabMainOutAmount(preProgram, next, context)
+				if err != nil {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_27, orth_types.StdWith, err, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				if len(ins.Links) > 0 {
+					emit(ins)
 				}
 			case orth_types.StdIn:
 				ins := parseToken(orth_types.StdIn, "", context, orth_types.InstructionIn)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdDo:
 				newContext := orth_types.Context{
 					Name:          fmt.Sprintf("c?_do_%d$", len(context.InnerContexts)),
@@ -327,126 +396,134 @@ func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_
 				context = &newContext
 
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionDo)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdDrop:
 				ins := parseToken(orth_types.StdVOID, "", context, orth_types.InstructionDrop)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdSwap:
 				ins := parseToken(orth_types.StdVOID, "", context, orth_types.InstructionSwap)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdMod:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionMod)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdMem:
 				ins := parseToken(orth_types.StdAddress, "0", context, orth_types.InstructionMem)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
+				emit(ins)
+			case orth_types.StdEnum:
+				enum, err := grabEnumDefinition(preProgram, i, context)
+				if err != nil {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_19, enum.Operator.Operand, err, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				if _, declared := enums[enum.Operator.Operand]; declared {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_02, "ENUM", enum.Operator.Operand, v.File, v.Index, v.Content.Index)))
+					continue
 				}
+				enums[enum.Operator.Operand] = enum
+
+				emit(enum)
+			case orth_types.StdMemory:
+				if i+2 >= len(preProgram) {
+					missing := "missing its size"
+					if i+1 >= len(preProgram) {
+						missing = "missing its name and size"
+					}
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_27, v.Content.Token, missing, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				preProgram[i+1].Content.ValidPos = true
+				preProgram[i+2].Content.ValidPos = true
+				rName := preProgram[i+1].Content.Token
+				rSize := preProgram[i+2].Content.Token
+
+				if context.Name != embedded_helpers.MainScope {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_17, rName, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				if _, declared := memoryRegions[rName]; declared {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_02, "MEMORY", rName, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				if size, err := strconv.Atoi(rSize); err != nil || size <= 0 {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_05, orth_types.InstructionToStr(orth_types.InstructionMemory), orth_types.INTS, rSize, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+
+				ins := parseToken(orth_types.StdMemory, rName, context, orth_types.InstructionMemory)
+				ins.Links["memory_size"] = parseToken(orth_types.StdINT, rSize, context, orth_types.InstructionPush)
+				memoryRegions[rName] = ins
+
+				emit(ins)
 			case orth_types.StdStore:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionStore)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdLoad:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionLoad)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdCall:
+				if i+1 >= len(preProgram) {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_13, orth_types.StdCall, 1, 0, v.File, v.Index, v.Content.Index)))
+					continue
+				}
 				preProgram[i+1].Content.ValidPos = true
 				ins := parseToken(orth_types.StdSTR, preProgram[i+1].Content.Token, context, orth_types.InstructionCall)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
+				emit(ins)
+			case orth_types.StdCallIndirect:
+				ins, emptySection := grabCallSignature(preProgram, i, context)
+				if emptySection != "" {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_14, emptySection, ">= 1", 0, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				emit(ins)
+			case orth_types.StdAsm:
+				ins, err := grabAsmBlock(preProgram, i, context)
+				if err != nil {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_22, err, v.File, v.Index, v.Content.Index)))
+					continue
 				}
+				if *orth_debug.Compile != "" && ins.Operator.SymbolName != *orth_debug.Compile {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_23, ins.Operator.SymbolName, *orth_debug.Compile, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				emit(ins)
 			case orth_types.StdLoadAndStay:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionLoadStay)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdType:
 				preProgram[i+1].Content.ValidPos = true
 
 				ins := parseToken(orth_types.StdType, preProgram[i+1].Content.Token, context, orth_types.InstructionPush)
 
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdConst:
-				vValue, vType, vName := grabVariableDefinition(preProgram, i)
-
-				if context.HasVariableDeclaredInOrAbove(vName) {
-					parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-						Left:  orth_types.Operation{},
-						Right: orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_03, "constant", vName, context.Name),
-					}
-					close(parsedOperation)
-					return
+				constant, err := grabDeclaration(preProgram, i, constants, context, globalInstructionIndex, orth_types.InstructionConst)
+				if err != nil {
+					report(preProgram, i, err)
+					continue
 				}
+				constants[constant.Operator.Operand] = constant
 
-				context.Declarations = append(context.Declarations, orth_types.ContextDeclaration{
-					Name:  vName,
-					Index: globalInstructionIndex,
-				})
-
-				value := parseToken(vType, vValue, context, orth_types.InstructionPush)
-				constant := parseToken(orth_types.StdConst, vName, context, orth_types.InstructionConst)
-				constant.Links = make(map[string]orth_types.Operation)
-				constant.Links["variable_value"] = value
-
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  constant,
-					Right: nil,
-				}
+				emit(constant)
 			case orth_types.StdVar:
-				vValue, vType, vName := grabVariableDefinition(preProgram, i)
-
-				if context.HasVariableDeclaredInOrAbove(vName) {
-					parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-						Left:  orth_types.Operation{},
-						Right: orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_03, "variable", vName, context.Name),
-					}
-					close(parsedOperation)
-					return
+				variable, err := grabDeclaration(preProgram, i, constants, context, globalInstructionIndex, orth_types.InstructionVar)
+				if err != nil {
+					report(preProgram, i, err)
+					continue
 				}
 
-				context.Declarations = append(context.Declarations, orth_types.ContextDeclaration{
-					Name:  vName,
-					Index: globalInstructionIndex,
-				})
-
-				value := parseToken(vType, vValue, context, orth_types.InstructionPush)
-				variable := parseToken(orth_types.StdVar, vName, context, orth_types.InstructionVar)
-				variable.Links = make(map[string]orth_types.Operation)
-				variable.Links["variable_value"] = value
-
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  variable,
-					Right: nil,
+				emit(variable)
+			case orth_types.StdCast:
+				castType, _ := grabType(preProgram, i+1)
+				if !orth_types.IsValidTypeSybl(castType) {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_12, castType, "Used as cast type", v.File, v.Index, v.Content.Index)))
+					continue
 				}
+
+				ins := parseToken(castType, "", context, orth_types.InstructionCast)
+				emit(ins)
 			case orth_types.StdDeref:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionDeref)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			// I hate this
 			// case orth_types.StdSetNumber:
 			// 	ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionSetNumber)
@@ -465,41 +542,89 @@ func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_
 				vName := preProgram[i+1].Content.Token
 
 				ins := parseToken(orth_types.StdHold, vName, context, orth_types.InstructionHold)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
+				emit(ins)
+			case orth_types.StdIndexLoad:
+				if i+1 >= len(preProgram) {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_13, v.Content.Token, 1, 0, v.File, v.Index, v.Content.Index)))
+					continue
 				}
+				preProgram[i+1].Content.ValidPos = true
+				vName := preProgram[i+1].Content.Token
+
+				ins := parseToken(orth_types.StdRNT, vName, context, orth_types.InstructionIndexLoad)
+				emit(ins)
+			case orth_types.StdIndexStore:
+				if i+1 >= len(preProgram) {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_13, v.Content.Token, 1, 0, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				preProgram[i+1].Content.ValidPos = true
+				vName := preProgram[i+1].Content.Token
+
+				ins := parseToken(orth_types.StdRNT, vName, context, orth_types.InstructionIndexStore)
+				emit(ins)
+			case orth_types.StdExtern:
+				ins, err := grabExternDefinition(preProgram, i, context)
+				if err != nil {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_24, ins.Operator.Operand, err, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				// only masm has a backend for `invoke`, the System V convention of the linux assemblers is not implemented
+				if *orth_debug.Compile != "" && *orth_debug.Compile != "masm" {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_25, ins.Operator.Operand, *orth_debug.Compile, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				if externs[ins.Operator.Operand] {
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_02, "EXTERN", ins.Operator.Operand, v.File, v.Index, v.Content.Index)))
+					continue
+				}
+				externs[ins.Operator.Operand] = true
+
+				emit(ins)
 			case orth_types.StdInvoke:
 				preProgram[i+1].Content.ValidPos = true
 				pName := preProgram[i+1].Content.Token
 
 				ins := parseToken(orth_types.StdRNT, pName, context, orth_types.InstructionInvoke)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdExit:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionExit)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
+				emit(ins)
+			case orth_types.StdAssert:
+				hasMessage := i+1 < len(preProgram) && strings.HasPrefix(preProgram[i+1].Content.Token, `"`)
+				if hasMessage {
+					preProgram[i+1].Content.ValidPos = true
+				}
+				location := fmt.Sprintf("%s:%d:%d", v.File, v.Index, v.Content.Index+1)
+				ins := parseToken(orth_types.StdRNT, location, context, orth_types.InstructionAssert)
+				if hasMessage {
+					message := preProgram[i+1].Content.Token
+					ins.Links["assert_message"] = parseToken(orth_types.StdSTR, message[1:len(message)-1], context, orth_types.InstructionPushStr)
 				}
+				// stripped assertions still have to consume the condition
+				if *orth_debug.NoAssert {
+					ins = parseToken(orth_types.StdVOID, "", context, orth_types.InstructionDrop)
+				}
+				emit(ins)
 			case orth_types.StdProcOutParams:
 				procOutTypeParams := make([]string, 0)
+				var err error
 				for offset := 1; offset < len(preProgram) &&
 					(preProgram[i+offset].Content.Token != orth_types.StdIn && preProgram[i+offset].Content.Token != orth_types.StdProcOutParams); offset++ {
-					if !orth_types.IsValidTypeSybl(preProgram[i+offset].Content.Token) {
-						err := orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_12, preProgram[i+offset].Content.Token, "Used as proc out param", file.Name, v.Index, v.Content.Index)
-						fmt.Fprintln(os.Stderr, err)
-						os.Exit(1)
+					paramType, consumed := grabType(preProgram, i+offset)
+					if !orth_types.IsValidTypeSybl(paramType) {
+						err = orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_12, paramType, "Used as proc out param", v.File, v.Index, v.Content.Index))
+						break
 					}
-					preProgram[i+offset].Content.ValidPos = true
-					procOutTypeParams = append(procOutTypeParams, orth_types.GrabType(preProgram[i+offset].Content.Token))
+					offset += consumed - 1
+					procOutTypeParams = append(procOutTypeParams, orth_types.GrabType(paramType))
+				}
+				if err == nil && len(procOutTypeParams) <= 0 {
+					err = orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_14, orth_types.StdProcOutParams, ">= 1", len(procOutTypeParams), v.File, v.Index, v.Content.Index))
 				}
-				if len(procOutTypeParams) <= 0 {
-					err := orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_14, orth_types.StdProcOutParams, ">= 1", len(procOutTypeParams), file.Name, v.Index, v.Content.Index)
-					fmt.Fprint(os.Stderr, err)
-					os.Exit(1)
+				if err != nil {
+					report(preProgram, i, err)
+					continue
 				}
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionOut)
 				for i, param := range procOutTypeParams {
@@ -513,27 +638,27 @@ func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_
 					}
 				}
 
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdProcInParams:
 				procTypeParams := make([]string, 0)
+				var err error
 				for offset := 1; offset < len(preProgram) &&
 					(preProgram[i+offset].Content.Token != orth_types.StdIn && preProgram[i+offset].Content.Token != orth_types.StdProcOutParams); offset++ {
-					if !orth_types.IsValidTypeSybl(preProgram[i+offset].Content.Token) {
-						err := orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_12, preProgram[i+offset].Content.Token, "Used as proc param", file.Name, v.Index, v.Content.Index)
-						fmt.Fprint(os.Stderr, err)
-						os.Exit(1)
+					paramType, consumed := grabType(preProgram, i+offset)
+					if !orth_types.IsValidTypeSybl(paramType) {
+						err = orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_12, paramType, "Used as proc param", v.File, v.Index, v.Content.Index))
+						break
 					}
-					preProgram[i+offset].Content.ValidPos = true
-					procTypeParams = append(procTypeParams, orth_types.GrabType(preProgram[i+offset].Content.Token))
+					offset += consumed - 1
+					procTypeParams = append(procTypeParams, orth_types.GrabType(paramType))
 				}
 
-				if len(procTypeParams) <= 0 {
-					err := orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_14, orth_types.StdProcInParams, ">= 1", len(procTypeParams), file.Name, v.Index, v.Content.Index)
-					fmt.Fprint(os.Stderr, err)
-					os.Exit(1)
+				if err == nil && len(procTypeParams) <= 0 {
+					err = orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_14, orth_types.StdProcInParams, ">= 1", len(procTypeParams), v.File, v.Index, v.Content.Index))
+				}
+				if err != nil {
+					report(preProgram, i, err)
+					continue
 				}
 
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionWith)
@@ -548,42 +673,90 @@ func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_
 					}
 				}
 
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdDumpMem:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionDumpMem)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdAlloc:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionAlloc)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdFree:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionFree)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
 			case orth_types.StdPutChar:
 				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionPutChar)
-				parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-					Left:  ins,
-					Right: nil,
-				}
+				emit(ins)
+			case orth_types.StdFOpen:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionFOpen)
+				emit(ins)
+			case orth_types.StdFRead:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionFRead)
+				emit(ins)
+			case orth_types.StdFWrite:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionFWrite)
+				emit(ins)
+			case orth_types.StdFClose:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionFClose)
+				emit(ins)
+			case orth_types.StdFSize:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionFSize)
+				emit(ins)
+			case orth_types.StdUnlink:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionUnlink)
+				emit(ins)
+			case orth_types.StdPutStrFd:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionPutStringFd)
+				emit(ins)
+			case orth_types.StdEWrite:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionEWrite)
+				emit(ins)
+			case orth_types.StdGetChar:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionGetChar)
+				emit(ins)
+			case orth_types.StdReadLine:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionReadLine)
+				emit(ins)
+			case orth_types.StdReadInt:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionReadInt)
+				emit(ins)
+			case orth_types.StdGetEnv:
+				ins := parseToken(orth_types.StdRNT, "", context, orth_types.FunctionGetEnv)
+				emit(ins)
+			case orth_types.StdSyscall0:
+				fallthrough
+			case orth_types.StdSyscall1:
+				fallthrough
+			case orth_types.StdSyscall2:
+				fallthrough
+			case orth_types.StdSyscall3:
+				fallthrough
+			case orth_types.StdSyscall4:
+				fallthrough
+			case orth_types.StdSyscall5:
+				fallthrough
+			case orth_types.StdSyscall6:
+				// raw syscalls need a Linux backend, masm targets Windows where syscall numbers are not stable
+				report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_37, v.Content.Token, v.File, v.Index, v.Content.Index)))
+				continue
 			default:
+				if procName, ok := strings.CutPrefix(v.Content.Token, orth_types.StdProcAddress); ok && procName != "" {
+					ins := parseToken(orth_types.StdAddress, procName, context, orth_types.InstructionProcAddress)
+					emit(ins)
+					break
+				}
+				if member, ok := enumMember(enums, v.Content.Token, context); ok {
+					emit(member)
+					break
+				}
+				if region, ok := memoryRegions[v.Content.Token]; ok && region.Operator.Operand != orth_types.StdMem {
+					ins := parseToken(orth_types.StdAddress, "0", context, orth_types.InstructionMem)
+					ins.Links["memory_region"] = region
+					emit(ins)
+					break
+				}
 				if !v.Content.ValidPos {
-					parsedOperation <- orth_types.Pair[orth_types.Operation, error]{
-						Left:  orth_types.Operation{},
-						Right: orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_01, v.Content.Token, file.Name, v.Index, v.Content.Index),
-					}
-					close(parsedOperation)
-					return
+					report(preProgram, i, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_01, v.Content.Token, v.File, v.Index, v.Content.Index)))
+					continue
 				}
 			}
 			globalInstructionIndex++
@@ -593,26 +766,367 @@ func ParseTokenAsOperation(tokenFiles []orth_types.File[orth_types.SliceOf[orth_
 	close(parsedOperation)
 }
 
-func grabVariableDefinition(preProgram []orth_types.StringEnum, i int) (string, string, string) {
+// skipStatement marks the tokens after an error at `i` as parsed up to the start of the next statement,
+// which is the next line or, for the blocks that are not made of instructions (`enum`, `asm`), the token after their `end`
+func skipStatement(preProgram []orth_types.StringEnum, i int) {
+	switch preProgram[i].Content.Token {
+	case orth_types.StdEnum, orth_types.StdAsm:
+		for x := i + 1; x < len(preProgram); x++ {
+			preProgram[x].Content.ValidPos = true
+			if preProgram[x].Content.Token == orth_types.StdEND {
+				return
+			}
+		}
+		return
+	}
+	for x := i + 1; x < len(preProgram) && preProgram[x].Index == preProgram[i].Index && preProgram[x].File == preProgram[i].File; x++ {
+		preProgram[x].Content.ValidPos = true
+	}
+}
+
+// grabType reads the type at position `i`, which is either a single token (`i32`) or
+// a pointer made of two tokens (`ptr i32`). Returns the type and how many tokens were consumed
+func grabType(preProgram []orth_types.StringEnum, i int) (string, int) {
+	if i >= len(preProgram) {
+		return orth_types.StdINVALID, 0
+	}
+	preProgram[i].Content.ValidPos = true
+	if preProgram[i].Content.Token != orth_types.StdPtr || i+1 >= len(preProgram) {
+		return preProgram[i].Content.Token, 1
+	}
+	preProgram[i+1].Content.ValidPos = true
+	return orth_types.PointerType(preProgram[i+1].Content.Token), 2
+}
+
+// grabEnumDefinition parses `enum Name Member1 Member2 ... end` into an operation
+// linking every member under `enum_member_<value>`
+func grabEnumDefinition(preProgram []orth_types.StringEnum, i int, context *orth_types.Context) (orth_types.Operation, error) {
 	re := regexp.MustCompile(`[^\w]`)
 
-	// check name
-	if re.Match([]byte(preProgram[i+1].Content.Token)) {
-		fmt.Fprintf(os.Stderr, "%s has invalid characters in it's composition\n", "const")
-		os.Exit(1)
+	enum := parseToken(orth_types.StdEnum, "", context, orth_types.InstructionEnum)
+	if i+1 >= len(preProgram) {
+		return enum, errors.New("missing enum name")
 	}
-	// check if has a value
-	if !orth_types.IsValidTypeSybl(preProgram[i+2].Content.Token) {
-		fmt.Fprintln(os.Stderr, "var/const must be initialized with a valid type")
-		os.Exit(1)
+	preProgram[i+1].Content.ValidPos = true
+	enum.Operator.Operand = preProgram[i+1].Content.Token
+	if re.Match([]byte(enum.Operator.Operand)) {
+		return enum, errors.New("name has invalid characters in it's composition")
+	}
+
+	members := make(map[string]bool)
+	for x := i + 2; x < len(preProgram); x++ {
+		preProgram[x].Content.ValidPos = true
+		member := preProgram[x].Content.Token
+
+		if member == orth_types.StdEND {
+			if len(members) == 0 {
+				return enum, errors.New("an enum requires at least one member")
+			}
+			return enum, nil
+		}
+		if re.Match([]byte(member)) {
+			return enum, fmt.Errorf("member %q has invalid characters in it's composition", member)
+		}
+		if member == orth_types.StdEnumCount {
+			return enum, fmt.Errorf("%q is reserved for the amount of members", orth_types.StdEnumCount)
+		}
+		if members[member] {
+			return enum, fmt.Errorf("member %q was declared more than once", member)
+		}
+		members[member] = true
+
+		enum.Links[fmt.Sprintf("enum_member_%d", len(members)-1)] = parseToken(orth_types.StdINT, member, context, orth_types.InstructionPush)
+	}
+	return enum, fmt.Errorf("missing %q", orth_types.StdEND)
+}
+
+// enumMember resolves tokens like `Color.Red` or `Color.count` into the push of its integer value
+func enumMember(enums map[string]orth_types.Operation, token string, context *orth_types.Context) (orth_types.Operation, bool) {
+	enumName, memberName, found := strings.Cut(token, ".")
+	enum, declared := enums[enumName]
+	if !found || !declared {
+		return orth_types.Operation{}, false
+	}
+
+	membersCount := 0
+	value := -1
+	for k, member := range enum.Links {
+		if !strings.HasPrefix(k, "enum_member_") {
+			continue
+		}
+		membersCount++
+		if member.Operator.Operand == memberName {
+			value, _ = strconv.Atoi(strings.TrimPrefix(k, "enum_member_"))
+		}
+	}
+	if memberName == orth_types.StdEnumCount {
+		value = membersCount
+	}
+	if value < 0 {
+		return orth_types.Operation{}, false
+	}
+
+	ins := parseToken(orth_types.StdINT, fmt.Sprint(value), context, orth_types.InstructionPush)
+	ins.Links["enum"] = enum
+	ins.Links["enum_member"] = parseToken(orth_types.StdINT, memberName, context, orth_types.InstructionPush)
+	return ins, true
+}
+
+// isSignatureType checks if the token at `x` is a type of a `call*` signature written on `line`.
+// Types followed by a value are the beginning of a literal push and end the signature
+func isSignatureType(preProgram []orth_types.StringEnum, x int, line int) (string, int, bool) {
+	if x >= len(preProgram) || preProgram[x].Index != line {
+		return orth_types.StdINVALID, 0, false
+	}
+	if _, isLiteral := literalAt(preProgram, x); isLiteral {
+		return orth_types.StdINVALID, 0, false
+	}
+	if x+1 < len(preProgram) && strings.HasPrefix(preProgram[x+1].Content.Token, "\"") {
+		return orth_types.StdINVALID, 0, false
+	}
+
+	paramType, consumed := preProgram[x].Content.Token, 1
+	if paramType == orth_types.StdPtr && x+1 < len(preProgram) {
+		paramType, consumed = orth_types.PointerType(preProgram[x+1].Content.Token), 2
+	}
+	return paramType, consumed, orth_types.IsValidTypeSybl(paramType)
+}
+
+// grabCallSignature parses the signature that follows a `call*`.
+// A section without types is returned as the second value
+func grabCallSignature(preProgram []orth_types.StringEnum, i int, context *orth_types.Context) (orth_types.Operation, string) {
+	ins := parseToken(orth_types.StdAddress, "", context, orth_types.InstructionCallIndirect)
+	_, emptySection := grabSignature(preProgram, i+1, preProgram[i].Index, &ins)
+	return ins, emptySection
+}
+
+// grabSignature parses the optional `: <types>` and `-- <types>` written on `line` starting at `x`
+// into the same links used by the signature of a proc, returning the position after it
+func grabSignature(preProgram []orth_types.StringEnum, x int, line int, ins *orth_types.Operation) (int, string) {
+	context := ins.Context
+	for _, section := range []struct{ token, prefix string }{
+		{orth_types.StdProcInParams, "proc_param_"},
+		{orth_types.StdProcOutParams, "proc_out_param_"},
+	} {
+		if x >= len(preProgram) || preProgram[x].Index != line || preProgram[x].Content.Token != section.token {
+			continue
+		}
+		preProgram[x].Content.ValidPos = true
+		x++
+
+		params := 0
+		for {
+			paramType, consumed, ok := isSignatureType(preProgram, x, line)
+			if !ok {
+				break
+			}
+			for c := 0; c < consumed; c++ {
+				preProgram[x+c].Content.ValidPos = true
+			}
+			ins.Links[fmt.Sprintf("%s%d", section.prefix, params)] = orth_types.Operation{
+				Instruction: orth_types.InstructionParam,
+				Context:     context,
+				Operator: orth_types.Operand{
+					SymbolName: orth_types.StdParam,
+					Operand:    orth_types.GrabType(paramType),
+				},
+			}
+			params++
+			x += consumed
+		}
+		if params == 0 {
+			return x, section.token
+		}
+	}
+	return x, ""
+}
+
+// grabMainOutAmount parses the `out <outs>` of `proc main with`, where the single value main can return
+// is the exit status, the same as `-- i64`
+func grabMainOutAmount(preProgram []orth_types.StringEnum, i int, context *orth_types.Context) (orth_types.Operation, error) {
+	ins := parseToken(orth_types.StdRNT, "", context, orth_types.InstructionOut)
+	preProgram[i].Content.ValidPos = true
+	if i+1 >= len(preProgram) {
+		return ins, fmt.Errorf("missing the amount of values after %q", orth_types.StdOut)
+	}
+	preProgram[i+1].Content.ValidPos = true
+
+	amount, err := strconv.Atoi(preProgram[i+1].Content.Token)
+	if err != nil || amount < 0 || amount > 1 {
+		return ins, fmt.Errorf("`main` can only return its exit status, expected %q followed by 0 or 1 but found %q", orth_types.StdOut, preProgram[i+1].Content.Token)
+	}
+	if amount == 1 {
+		ins.Links["proc_out_param_0"] = orth_types.Operation{
+			Instruction: orth_types.InstructionParam,
+			Context:     context,
+			Operator: orth_types.Operand{
+				SymbolName: orth_types.StdParam,
+				Operand:    orth_types.StdI64,
+			},
+		}
+	}
+	return ins, nil
+}
+
+// grabExternDefinition parses `extern <name> : <arg types> -- <ret type>`
+func grabExternDefinition(preProgram []orth_types.StringEnum, i int, context *orth_types.Context) (orth_types.Operation, error) {
+	ins := parseToken(orth_types.StdExtern, "", context, orth_types.InstructionExtern)
+	if i+1 >= len(preProgram) {
+		return ins, errors.New("missing the name of the foreign function")
+	}
+	preProgram[i+1].Content.ValidPos = true
+	ins.Operator.Operand = preProgram[i+1].Content.Token
+	if regexp.MustCompile(`[^\w]`).Match([]byte(ins.Operator.Operand)) {
+		return ins, errors.New("name has invalid characters in it's composition")
+	}
+
+	_, emptySection := grabSignature(preProgram, i+2, preProgram[i].Index, &ins)
+	if emptySection != "" {
+		return ins, fmt.Errorf("missing types after %q", emptySection)
+	}
+	if _, multipleReturns := ins.Links["proc_out_param_1"]; multipleReturns {
+		return ins, errors.New("a foreign function can only return a single value")
+	}
+	return ins, nil
+}
+
+// grabAsmBlock parses `asm "<backend>" <signature> ... end`, keeping the lines of the body as they were written
+func grabAsmBlock(preProgram []orth_types.StringEnum, i int, context *orth_types.Context) (orth_types.Operation, error) {
+	ins := parseToken(orth_types.StdINVALID, "", context, orth_types.InstructionAsm)
+	if i+1 >= len(preProgram) || !strings.HasPrefix(preProgram[i+1].Content.Token, "\"") {
+		return ins, errors.New("missing the assembler of the block, ex: asm \"masm\"")
+	}
+	preProgram[i+1].Content.ValidPos = true
+
+	backend, err := functions.CheckAsmType(strings.Trim(preProgram[i+1].Content.Token, "\""))
+	if err != nil {
+		return ins, fmt.Errorf("%w %s", err, preProgram[i+1].Content.Token)
+	}
+	ins.Operator.SymbolName = backend
+
+	x, emptySection := grabSignature(preProgram, i+2, preProgram[i].Index, &ins)
+	if emptySection != "" {
+		return ins, fmt.Errorf("missing types after %q", emptySection)
+	}
+
+	body := strings.Builder{}
+	line := preProgram[i].Index
+	lineLength := 0
+	for ; x < len(preProgram) && preProgram[x].Content.Token != orth_types.StdEND; x++ {
+		preProgram[x].Content.ValidPos = true
+		token := preProgram[x].Content
+
+		if preProgram[x].Index != line {
+			if body.Len() > 0 {
+				body.WriteString("\n")
+			}
+			line = preProgram[x].Index
+			lineLength = 0
+		}
+		// keeps the indentation and the spacing between tokens of the line
+		padding := token.Index - lineLength
+		if lineLength > 0 && padding < 1 {
+			padding = 1
+		}
+		body.WriteString(strings.Repeat(" ", padding))
+		body.WriteString(token.Token)
+		lineLength = token.Index + len(token.Token)
+	}
+	if x >= len(preProgram) {
+		return ins, fmt.Errorf("missing %q", orth_types.StdEND)
+	}
+	preProgram[x].Content.ValidPos = true
+
+	ins.Operator.Operand = body.String()
+	return ins, nil
+}
+
+// definitionStart returns the position of the first token after a var/const name,
+// skipping the optional "=" between the name and its value
+func definitionStart(preProgram []orth_types.StringEnum, i int) int {
+	if i+2 < len(preProgram) && preProgram[i+2].Content.Token == orth_types.StdAssign {
+		preProgram[i+2].Content.ValidPos = true
+		return i + 3
 	}
+	return i + 2
+}
+
+// grabDeclaration parses the `const` or `var` at `i`, an array, a constant expression or a single value,
+// declaring its name on `context`
+func grabDeclaration(preProgram []orth_types.StringEnum, i int, constants map[string]orth_types.Operation, context *orth_types.Context, index uint, instruction orth_types.Instruction) (orth_types.Operation, error) {
+	v := preProgram[i]
+	var vValue, vType, vName string
+	var arrayLength int
+	if i+1 >= len(preProgram) {
+		return orth_types.Operation{}, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_27, v.Content.Token, "missing its name", v.File, v.Index, v.Content.Index))
+	}
+	if isArrayDefinition(preProgram, i) {
+		var err error
+		vValue, vType, vName, arrayLength, err = grabArrayDefinition(preProgram, i)
+		if err != nil {
+			return orth_types.Operation{}, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_16, vName, err, v.File, v.Index, v.Content.Index))
+		}
+	} else {
+		var isExpression bool
+		var err error
+		vName = preProgram[i+1].Content.Token
+		vValue, vType, isExpression, err = grabConstantExpression(preProgram, i, constants, context)
+		if err != nil {
+			return orth_types.Operation{}, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_20, vName, err, v.File, v.Index, v.Content.Index))
+		}
+		if !isExpression {
+			vValue, vType, vName, err = grabVariableDefinition(preProgram, i)
+			if err != nil {
+				return orth_types.Operation{}, orth_debug.TokenError(v, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_27, v.Content.Token, err, v.File, v.Index, v.Content.Index))
+			}
+		}
+	}
+
+	kind := "variable"
+	if instruction == orth_types.InstructionConst {
+		kind = "constant"
+	}
+	if context.HasVariableDeclaredInOrAbove(vName) {
+		return orth_types.Operation{}, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_03, kind, vName, context.Name)
+	}
+
+	context.Declarations = append(context.Declarations, orth_types.ContextDeclaration{
+		Name:  vName,
+		Index: index,
+	})
+
+	value := parseToken(vType, vValue, context, orth_types.InstructionPush)
+	declaration := parseToken(v.Content.Token, vName, context, instruction)
+	declaration.Links = make(map[string]orth_types.Operation)
+	declaration.Links["variable_value"] = value
+	if arrayLength > 0 {
+		declaration.Links["array_length"] = parseToken(orth_types.StdINT, fmt.Sprint(arrayLength), context, orth_types.InstructionPush)
+	}
+	return declaration, nil
+}
 
-	for x := 1; x < 4; x++ {
-		preProgram[i+x].Content.ValidPos = true
+func grabVariableDefinition(preProgram []orth_types.StringEnum, i int) (string, string, string, error) {
+	re := regexp.MustCompile(`[^\w]`)
+
+	// check name, the declarations of modules being scoped like contexts
+	if re.Match([]byte(orth_types.UnscopedName(preProgram[i+1].Content.Token))) {
+		return "", "", "", errors.New("name has invalid characters in it's composition")
+	}
+	start := definitionStart(preProgram, i)
+	varType, consumed := grabType(preProgram, start)
+	// check if has a value
+	if !orth_types.IsValidTypeSybl(varType) {
+		return "", "", "", errors.New("it must be initialized with a valid type")
+	}
+	valueAt := start + consumed
+	if valueAt >= len(preProgram) {
+		return "", "", "", errors.New("it must be initialized with a value")
 	}
 
+	preProgram[i+1].Content.ValidPos = true
+	preProgram[valueAt].Content.ValidPos = true
+
 	varName := preProgram[i+1].Content.Token
-	varType := preProgram[i+2].Content.Token
 
 	var varValue string
 
@@ -620,12 +1134,92 @@ func grabVariableDefinition(preProgram []orth_types.StringEnum, i int) (string,
 	case orth_types.StdSTR:
 		fallthrough
 	case orth_types.RNGABL:
-		varValue = preProgram[i+3].Content.Token[1 : len(preProgram[i+3].Content.Token)-1]
+		varValue = preProgram[valueAt].Content.Token[1 : len(preProgram[valueAt].Content.Token)-1]
 	default:
-		varValue = preProgram[i+3].Content.Token
+		varValue = preProgram[valueAt].Content.Token
+	}
+
+	return varValue, varType, varName, nil
+}
+
+// isArrayDefinition checks if the var/const at `i` is declared as `[type n]` or `[type v1 v2 ...]`
+func isArrayDefinition(preProgram []orth_types.StringEnum, i int) bool {
+	if i+2 >= len(preProgram) {
+		return false
+	}
+	start := i + 2
+	if preProgram[start].Content.Token == orth_types.StdAssign && start+1 < len(preProgram) {
+		start++
+	}
+	return strings.HasPrefix(preProgram[start].Content.Token, orth_types.StdArrayOpen)
+}
+
+// grabArrayDefinition parses a fixed-size array declaration.
+// A single value after the type is the amount of elements (`[i8 256]`), more values
+// are the initial elements of the array (`[i32 2 3 5 7]`)
+func grabArrayDefinition(preProgram []orth_types.StringEnum, i int) (string, string, string, int, error) {
+	re := regexp.MustCompile(`[^\w]`)
+
+	varName := preProgram[i+1].Content.Token
+	if re.Match([]byte(orth_types.UnscopedName(varName))) {
+		return "", "", varName, 0, errors.New("name has invalid characters in it's composition")
+	}
+	preProgram[i+1].Content.ValidPos = true
+
+	closed := false
+	definition := make([]string, 0)
+	for x := definitionStart(preProgram, i); x < len(preProgram) && !closed; x++ {
+		preProgram[x].Content.ValidPos = true
+		definition = append(definition, preProgram[x].Content.Token)
+		closed = strings.HasSuffix(preProgram[x].Content.Token, orth_types.StdArrayClose)
+	}
+	if !closed {
+		return "", "", varName, 0, fmt.Errorf("missing %q", orth_types.StdArrayClose)
+	}
+
+	joined := strings.Join(definition, " ")
+	joined = strings.TrimPrefix(joined, orth_types.StdArrayOpen)
+	joined = strings.TrimSuffix(joined, orth_types.StdArrayClose)
+	fields := strings.Fields(joined)
+
+	if len(fields) < 2 {
+		return "", "", varName, 0, errors.New("an array requires a type and a length or a list of values")
+	}
+
+	varType := fields[0]
+	_, isInt := orth_types.GlobalTypes[orth_types.INTS][varType]
+	_, isFloat := orth_types.GlobalTypes[orth_types.FLOATS][varType]
+	if !isInt && !isFloat {
+		return "", "", varName, 0, fmt.Errorf("type %q can not be used as an array element", varType)
+	}
+
+	values := fields[1:]
+	if len(values) == 1 {
+		length, err := strconv.Atoi(values[0])
+		if err != nil || length <= 0 {
+			return "", "", varName, 0, fmt.Errorf("invalid array length %q", values[0])
+		}
+		return "", varType, varName, length, nil
+	}
+
+	// the assembler would silently truncate a value wider than the element
+	_, width := embedded_helpers.VarTypeToPtrSize(orth_types.Operand{SymbolName: varType})
+	for _, value := range values {
+		var err error
+		if isInt {
+			_, err = strconv.ParseInt(value, 0, 8*width)
+		} else {
+			_, err = strconv.ParseFloat(value, 8*width)
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			return "", "", varName, 0, fmt.Errorf("value %q does not fit an element of %q", value, varType)
+		}
+		if err != nil {
+			return "", "", varName, 0, fmt.Errorf("invalid value %q for an array of %q", value, varType)
+		}
 	}
 
-	return varValue, varType, varName
+	return strings.Join(values, ","), varType, varName, len(values), nil
 }
 
 // parseToken parses a single token into a instruction
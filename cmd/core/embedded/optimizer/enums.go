@@ -0,0 +1,83 @@
+package optimizer
+
+import (
+	"fmt"
+	"orth/cmd/core/orth_debug"
+	orth_types "orth/cmd/pkg/types"
+	"strings"
+)
+
+type enumComparison struct {
+	Proc     string
+	Enum     orth_types.Operation
+	Compared map[string]bool
+	// At is the first comparison of the chain
+	At orth_types.Location
+}
+
+// enumMembers returns the members of an enum declaration ordered by their values
+func enumMembers(enum orth_types.Operation) []string {
+	members := make([]string, 0)
+	for i := 0; ; i++ {
+		member, ok := enum.Links[fmt.Sprintf("enum_member_%d", i)]
+		if !ok {
+			return members
+		}
+		members = append(members, member.Operator.Operand)
+	}
+}
+
+// checkEnumComparisons warns about procedures comparing a value against more than one member
+// of an enum with `==` without covering all of its members
+func checkEnumComparisons(operations []orth_types.Operation) []orth_types.CompilerMessage {
+	warnings := make([]orth_types.CompilerMessage, 0)
+	comparisons := make([]*enumComparison, 0)
+	proc := "_global"
+
+	for i, operation := range operations {
+		if operation.Instruction == orth_types.InstructionProc {
+			proc = operation.Operator.Operand
+			continue
+		}
+
+		enum, ok := operation.Links["enum"]
+		if !ok || i+1 >= len(operations) || operations[i+1].Instruction != orth_types.InstructionEqual {
+			continue
+		}
+
+		var comparison *enumComparison
+		for _, c := range comparisons {
+			if c.Proc == proc && c.Enum.Operator.Operand == enum.Operator.Operand {
+				comparison = c
+				break
+			}
+		}
+		if comparison == nil {
+			comparison = &enumComparison{Proc: proc, Enum: enum, Compared: make(map[string]bool), At: operation.Location}
+			comparisons = append(comparisons, comparison)
+		}
+		comparison.Compared[operation.Links["enum_member"].Operator.Operand] = true
+	}
+
+	for _, comparison := range comparisons {
+		// a single comparison is just a check, not a chain
+		if len(comparison.Compared) < 2 {
+			continue
+		}
+
+		missing := make([]string, 0)
+		for _, member := range enumMembers(comparison.Enum) {
+			if !comparison.Compared[member] {
+				missing = append(missing, member)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		warnings = append(warnings, orth_debug.BuildWarning(orth_types.Commom, comparison.At,
+			orth_debug.ORTH_WARN_02, comparison.Enum.Operator.Operand, comparison.Proc, strings.Join(missing, ", ")))
+	}
+
+	return warnings
+}
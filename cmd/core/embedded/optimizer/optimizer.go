@@ -1,6 +1,7 @@
 package optimizer
 
 import (
+	"orth/cmd/core/orth_debug"
 	orth_types "orth/cmd/pkg/types"
 )
 
@@ -8,6 +9,10 @@ func AnalyzeAndOptimizeOperations(operations []orth_types.Operation) ([]orth_typ
 	//stack := make([]orth_types.Operation, 0)
 	warnings := make([]orth_types.CompilerMessage, 0)
 
+	if *orth_debug.WarnEnum {
+		warnings = append(warnings, checkEnumComparisons(operations)...)
+	}
+
 	return operations, warnings
 
 	// for _, operation := range operations {
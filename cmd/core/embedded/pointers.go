@@ -0,0 +1,352 @@
+package embedded
+
+import (
+	"fmt"
+	embedded_helpers "orth/cmd/core/embedded/helpers"
+	"orth/cmd/core/orth_debug"
+	"orth/cmd/pkg/helpers"
+	orth_types "orth/cmd/pkg/types"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type typeBlock struct {
+	Instruction orth_types.Instruction
+	Types       []string
+}
+
+// typeStack keeps track of the types present on the stack during TypeCheckPointers,
+// anything that can not be known statically is treated as `rnt`
+type typeStack []string
+
+func (s *typeStack) push(types ...string) {
+	*s = append(*s, types...)
+}
+
+func (s *typeStack) pop() string {
+	if len(*s) == 0 {
+		return orth_types.StdRNT
+	}
+	t := (*s)[len(*s)-1]
+	*s = (*s)[:len(*s)-1]
+	return t
+}
+
+func (s typeStack) snapshot() []string {
+	types := make([]string, len(s))
+	copy(types, s)
+	return types
+}
+
+// orderedParams returns the params of a `:`/`--` operation in their declaration order
+func orderedParams(operation orth_types.Operation, prefix string) []string {
+	keys := make([]string, 0, len(operation.Links))
+	for k := range operation.Links {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return len(keys[i]) < len(keys[j]) || (len(keys[i]) == len(keys[j]) && keys[i] < keys[j])
+	})
+
+	params := make([]string, len(keys))
+	for i, k := range keys {
+		params[i] = operation.Links[k].Operator.Operand
+	}
+	return params
+}
+
+// procSignature returns the in and out types of a proc in their declaration order
+func procSignature(program orth_types.Program, name string) ([]string, []string, bool) {
+	for procIndex, op := range program.Operations {
+		if op.Instruction != orth_types.InstructionProc || op.Operator.Operand != name {
+			continue
+		}
+		ins := make([]string, 0)
+		outs := make([]string, 0)
+		for _, procOperation := range program.Operations[procIndex+1:] {
+			switch procOperation.Instruction {
+			case orth_types.InstructionWith:
+				ins = orderedParams(procOperation, "proc_param_")
+			case orth_types.InstructionOut:
+				outs = orderedParams(procOperation, "proc_out_param_")
+			case orth_types.InstructionIn:
+				return ins, outs, true
+			}
+		}
+		return ins, outs, true
+	}
+	return nil, nil, false
+}
+
+// variableType returns the type of the values stored by a var/const
+func variableType(variable orth_types.Operation) string {
+	return variable.Links["variable_value"].Operator.SymbolName
+}
+
+// TypeCheckPointers walks the program keeping track of the types on the stack.
+// It rejects arithmetic between pointers of distinct pointee types and links `deref`, `,` and `.`
+// to the type they point to, so the compilation can use the width of the pointee
+func TypeCheckPointers(program orth_types.Program) (orth_types.Program, error) {
+	types := make(typeStack, 0)
+	blocks := make([]typeBlock, 0)
+	procName := ""
+
+	for ip, operation := range program.Operations {
+		switch operation.Instruction {
+		case orth_types.InstructionPush:
+			fallthrough
+		case orth_types.InstructionPushStr:
+			types.push(operation.Operator.SymbolName)
+		case orth_types.InstructionMem:
+			types.push(orth_types.PointerType(orth_types.StdI8))
+		case orth_types.InstructionHold:
+			variable, ok := operation.Links["hold_local"]
+			if !ok {
+				variable = operation.Links["hold_mult"]
+			}
+			types.push(orth_types.PointerType(variableType(variable)))
+		case orth_types.InstructionCast:
+			types.pop()
+			types.push(operation.Operator.SymbolName)
+		case orth_types.InstructionLoad:
+			fallthrough
+		case orth_types.InstructionDeref:
+			pointer := types.pop()
+			if !orth_types.IsPointerType(pointer) {
+				types.push(orth_types.StdRNT)
+				continue
+			}
+			pointee := orth_types.PointeeType(pointer)
+			program.Operations[ip].Links["pointee"] = parseToken(pointee, "", operation.Context, orth_types.InstructionParam)
+			types.push(pointee)
+		case orth_types.InstructionStore:
+			types.pop()
+			pointer := types.pop()
+			if orth_types.IsPointerType(pointer) {
+				pointee := orth_types.PointeeType(pointer)
+				program.Operations[ip].Links["pointee"] = parseToken(pointee, "", operation.Context, orth_types.InstructionParam)
+			}
+		case orth_types.InstructionSum:
+			fallthrough
+		case orth_types.InstructionMinus:
+			fallthrough
+		case orth_types.InstructionMult:
+			fallthrough
+		case orth_types.InstructionDiv:
+			fallthrough
+		case orth_types.InstructionMod:
+			right := types.pop()
+			left := types.pop()
+			pointerType, isPointerArithmetic, err := helpers.PointerArithmeticType(
+				orth_types.Operand{SymbolName: left},
+				orth_types.Operand{SymbolName: right},
+			)
+			if err != nil {
+				return program, orth_debug.Locate(operation.Location, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_18, orth_types.InstructionToStr(operation.Instruction), left, right))
+			}
+			switch {
+			case isPointerArithmetic:
+				types.push(pointerType)
+			case left == right:
+				types.push(left)
+			default:
+				types.push(orth_types.StdRNT)
+			}
+		case orth_types.InstructionEqual:
+			fallthrough
+		case orth_types.InstructionNotEqual:
+			fallthrough
+		case orth_types.InstructionLt:
+			fallthrough
+		case orth_types.InstructionGt:
+			types.pop()
+			types.pop()
+			types.push(orth_types.StdBOOL)
+		case orth_types.InstructionLShift:
+			fallthrough
+		case orth_types.InstructionRShift:
+			fallthrough
+		case orth_types.InstructionLAnd:
+			fallthrough
+		case orth_types.InstructionLOr:
+			types.pop()
+			left := types.pop()
+			types.push(left)
+		case orth_types.InstructionDup:
+			top := types.pop()
+			types.push(top, top)
+		case orth_types.InstructionTwoDup:
+			top := types.pop()
+			below := types.pop()
+			types.push(below, top, below, top)
+		case orth_types.InstructionOver:
+			top := types.pop()
+			below := types.pop()
+			types.push(below, top, below)
+		case orth_types.InstructionSwap:
+			top := types.pop()
+			below := types.pop()
+			types.push(top, below)
+		case orth_types.FunctionPutU64:
+			fallthrough
+		case orth_types.FunctionPutI64:
+			fallthrough
+		case orth_types.FunctionPutHex:
+			fallthrough
+		case orth_types.FunctionPutBin:
+			fallthrough
+		case orth_types.FunctionPutBool:
+			fallthrough
+		case orth_types.FunctionPutString:
+			fallthrough
+		case orth_types.FunctionPutChar:
+			fallthrough
+		case orth_types.FunctionEWrite:
+			fallthrough
+		case orth_types.FunctionFClose:
+			fallthrough
+		case orth_types.FunctionFree:
+			fallthrough
+		case orth_types.InstructionAssert:
+			fallthrough
+		case orth_types.InstructionExit:
+			fallthrough
+		case orth_types.InstructionDrop:
+			types.pop()
+		case orth_types.FunctionDumpMem:
+			fallthrough
+		case orth_types.FunctionPutStringFd:
+			fallthrough
+		case orth_types.FunctionPutU64Pad:
+			fallthrough
+		case orth_types.InstructionIndexStore:
+			types.pop()
+			types.pop()
+		case orth_types.FunctionFSize:
+			fallthrough
+		case orth_types.FunctionUnlink:
+			types.pop()
+			types.push(orth_types.StdI64)
+		case orth_types.FunctionGetEnv:
+			types.pop()
+			types.push(orth_types.StdSTR)
+		case orth_types.FunctionGetChar:
+			fallthrough
+		case orth_types.FunctionReadInt:
+			types.push(orth_types.StdI64)
+		case orth_types.FunctionReadLine:
+			fallthrough
+		case orth_types.FunctionFOpen:
+			types.pop()
+			types.pop()
+			types.push(orth_types.StdI64)
+		case orth_types.FunctionFRead:
+			fallthrough
+		case orth_types.FunctionFWrite:
+			types.pop()
+			types.pop()
+			types.pop()
+			types.push(orth_types.StdI64)
+		case orth_types.FunctionAlloc:
+			types.pop()
+			types.push(orth_types.StdAddress)
+		case orth_types.InstructionIndexLoad:
+			types.pop()
+			types.push(variableType(operation.Links["array"]))
+		case orth_types.InstructionCall:
+			schema, err := program.FindProc(operation)
+			if err != nil {
+				return program, orth_debug.Locate(operation.Location, fmt.Errorf("%w\n", err))
+			}
+			for range schema.InParamsAmount {
+				types.pop()
+			}
+			for procIndex, op := range program.Operations {
+				if op.Instruction != orth_types.InstructionProc || op.Operator.Operand != operation.Operator.Operand {
+					continue
+				}
+				for _, procOperation := range program.Operations[procIndex:] {
+					if procOperation.Instruction == orth_types.InstructionOut {
+						types.push(orderedParams(procOperation, "proc_out_param_")...)
+						break
+					}
+				}
+				break
+			}
+		case orth_types.InstructionProcAddress:
+			types.push(orth_types.StdProcAddress + operation.Operator.Operand)
+		case orth_types.InstructionCallIndirect:
+			target := types.pop()
+			ins := orderedParams(operation, "proc_param_")
+			outs := orderedParams(operation, "proc_out_param_")
+			// the signature can only be checked when the address comes from a `&proc` on the same flow
+			if procName, known := strings.CutPrefix(target, orth_types.StdProcAddress); known {
+				procIns, procOuts, _ := procSignature(program, procName)
+				if !slices.Equal(ins, procIns) || !slices.Equal(outs, procOuts) {
+					return program, orth_debug.Locate(operation.Location, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_21,
+						orth_types.StdCallIndirect, strings.Join(ins, " "), strings.Join(outs, " "),
+						procName, strings.Join(procIns, " "), strings.Join(procOuts, " ")))
+				}
+			}
+			for range ins {
+				types.pop()
+			}
+			types.push(outs...)
+		case orth_types.InstructionInvoke:
+			operation = operation.Links["extern"]
+			fallthrough
+		case orth_types.InstructionAsm:
+			for range orderedParams(operation, "proc_param_") {
+				types.pop()
+			}
+			types.push(orderedParams(operation, "proc_out_param_")...)
+		case orth_types.InstructionProc:
+			types = types[:0]
+			blocks = append(blocks, typeBlock{Instruction: operation.Instruction})
+			procName = operation.Operator.Operand
+		case orth_types.InstructionOut:
+			// the out param of main becomes the exit status of the process
+			outs := orderedParams(operation, "proc_out_param_")
+			if procName == "main" && (len(outs) != 1 || orth_types.GlobalTypes[orth_types.INTS][outs[0]] == "") {
+				return program, orth_debug.Locate(operation.Location, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_26, procName, strings.Join(outs, " ")))
+			}
+		case orth_types.InstructionWith:
+			if strings.HasPrefix(operation.Operator.Operand, orth_types.StdCli) {
+				if strings.HasSuffix(operation.Operator.Operand, orth_types.StdEnv) {
+					types.push(orth_types.StdAddress)
+				}
+				// argv below argc
+				types.push(orth_types.StdAddress, orth_types.StdI64)
+				continue
+			}
+			params := orderedParams(operation, "proc_param_")
+			// the first param is the one on top of the stack
+			for i := len(params) - 1; i >= 0; i-- {
+				types.push(params[i])
+			}
+		case orth_types.InstructionIf:
+			types.pop()
+			blocks = append(blocks, typeBlock{Instruction: operation.Instruction, Types: types.snapshot()})
+		case orth_types.InstructionElse:
+			ifBlock := embedded_helpers.PopLast(&blocks)
+			blocks = append(blocks, typeBlock{Instruction: operation.Instruction, Types: types.snapshot()})
+			types = ifBlock.Types
+		case orth_types.InstructionDo:
+			types.pop()
+			blocks = append(blocks, typeBlock{Instruction: operation.Instruction, Types: types.snapshot()})
+		case orth_types.InstructionEnd:
+			block := embedded_helpers.PopLast(&blocks)
+			switch block.Instruction {
+			case orth_types.InstructionDo:
+				types = block.Types
+			case orth_types.InstructionProc:
+				types = types[:0]
+			}
+		}
+	}
+
+	return program, nil
+}
@@ -0,0 +1,181 @@
+package lexer
+
+import (
+	"fmt"
+	"orth/cmd/core/orth_debug"
+	orth_types "orth/cmd/pkg/types"
+	"runtime"
+	"strings"
+)
+
+const (
+	directiveIf     = "@if"
+	directiveIfdef  = "@ifdef"
+	directiveIfndef = "@ifndef"
+	directiveElif   = "@elif"
+	directiveElse   = "@else"
+	directiveEndif  = "@endif"
+)
+
+// conditional is an open `@if` block, `active` telling if the tokens of the current branch are kept
+type conditional struct {
+	at       orth_types.StringEnum
+	active   bool
+	taken    bool
+	elseSeen bool
+}
+
+func isConditionalDirective(directive string) bool {
+	switch directive {
+	case directiveIf, directiveIfdef, directiveIfndef, directiveElif, directiveElse, directiveEndif:
+		return true
+	}
+	return false
+}
+
+// skipping checks if the tokens are within a branch that is not compiled
+func skipping(conditionals []conditional) bool {
+	return len(conditionals) != 0 && !conditionals[len(conditionals)-1].active
+}
+
+// literalValue returns the text of a token, without the quotes of a string literal
+func literalValue(token orth_types.StringEnum) string {
+	if token.Content.Kind == orth_types.TokenString {
+		return strings.Trim(token.Content.Token, `"`)
+	}
+	return token.Content.Token
+}
+
+// evalCondition evaluates the condition of an `@if` or `@elif`, either a single value that is true unless
+// it is empty, `0`, `false` or an undefined name, or the comparison of two values with `==`, `!=` or `<>`
+func (p *preprocessor) evalCondition(directive orth_types.StringEnum, args []orth_types.StringEnum) (bool, error) {
+	expanded := p.expand(args)
+	switch len(expanded) {
+	case 1:
+		value := literalValue(expanded[0])
+		_, defined := p.defines[value]
+		undefinedName := expanded[0].Content.Kind == orth_types.TokenIdentifier && !defined
+		return !(undefinedName || value == "" || value == "0" || value == "false"), nil
+	case 3:
+		left, right := literalValue(expanded[0]), literalValue(expanded[2])
+		switch expanded[1].Content.Token {
+		case orth_types.StdEquals:
+			return left == right, nil
+		case "!=", orth_types.StdNotEquals:
+			return left != right, nil
+		}
+	}
+	return false, orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directive.Content.Token, "expected a value or a comparison using '==', '!=' or '<>'", directive.File, directive.Index, directive.Content.Index))
+}
+
+// definedName evaluates the single name of an `@ifdef` or `@ifndef`
+func (p *preprocessor) definedName(directive orth_types.StringEnum, args []orth_types.StringEnum) (bool, error) {
+	if len(args) != 1 {
+		return false, orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directive.Content.Token, "expected a single name", directive.File, directive.Index, directive.Content.Index))
+	}
+	_, defined := p.defines[args[0].Content.Token]
+	return defined, nil
+}
+
+// conditionalDirective opens, switches the branch of, or closes the innermost `@if` block of `conditionals`
+func (p *preprocessor) conditionalDirective(conditionals []conditional, directive orth_types.StringEnum, args []orth_types.StringEnum) ([]conditional, error) {
+	unexpected := func(reason string) error {
+		return orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directive.Content.Token, reason, directive.File, directive.Index, directive.Content.Index))
+	}
+
+	switch directive.Content.Token {
+	case directiveIf, directiveIfdef, directiveIfndef:
+		// blocks within a skipped branch are skipped as a whole without evaluating their conditions
+		if skipping(conditionals) {
+			return append(conditionals, conditional{at: directive, taken: true}), nil
+		}
+		var condition bool
+		var err error
+		switch directive.Content.Token {
+		case directiveIf:
+			condition, err = p.evalCondition(directive, args)
+		case directiveIfdef:
+			condition, err = p.definedName(directive, args)
+		case directiveIfndef:
+			condition, err = p.definedName(directive, args)
+			condition = !condition
+		}
+		if err != nil {
+			return nil, err
+		}
+		return append(conditionals, conditional{at: directive, active: condition, taken: condition}), nil
+	}
+
+	if len(conditionals) == 0 {
+		return nil, unexpected(fmt.Sprintf("there is no %s open", directiveIf))
+	}
+	current := &conditionals[len(conditionals)-1]
+	switch directive.Content.Token {
+	case directiveElif:
+		if current.elseSeen {
+			return nil, unexpected(fmt.Sprintf("found after %s", directiveElse))
+		}
+		current.active = false
+		if !current.taken {
+			condition, err := p.evalCondition(directive, args)
+			if err != nil {
+				return nil, err
+			}
+			current.active, current.taken = condition, condition
+		}
+	case directiveElse:
+		if current.elseSeen || len(args) != 0 {
+			return nil, unexpected(fmt.Sprintf("a block can only have one %s, without a condition", directiveElse))
+		}
+		current.elseSeen = true
+		current.active = !current.taken
+		current.taken = true
+	case directiveEndif:
+		if len(args) != 0 {
+			return nil, unexpected("unexpected tokens after it")
+		}
+		conditionals = conditionals[:len(conditionals)-1]
+	}
+	return conditionals, nil
+}
+
+// targetOS is the OS of the programs built for the selected assembly, the host one when simulating without a target
+func targetOS() string {
+	switch *orth_debug.Compile {
+	case "masm":
+		return "windows"
+	case "nasm", "fasm":
+		return "linux"
+	}
+	return runtime.GOOS
+}
+
+// predefine declares `__TARGET__`, `__OS__` and the names passed with `-D` as `NAME=value` or just `NAME`
+func (p *preprocessor) predefine() error {
+	predefined := map[string]string{
+		"__TARGET__": fmt.Sprintf("%q", *orth_debug.Compile),
+		"__OS__":     fmt.Sprintf("%q", targetOS()),
+	}
+	order := []string{"__TARGET__", "__OS__"}
+
+	for _, definition := range *orth_debug.D {
+		name, value, hasValue := strings.Cut(definition, "=")
+		if !hasValue {
+			value = "1"
+		}
+		if _, ok := predefined[name]; ok || !macroParam.MatchString(name) {
+			return orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, "-D", fmt.Sprintf("can not define %q", name), "command line", 0, 0)
+		}
+		predefined[name] = value
+		order = append(order, name)
+	}
+
+	for _, name := range order {
+		value, err := ScanFile("command line", predefined[name])
+		if err != nil {
+			return err
+		}
+		p.defines[name] = value
+	}
+	return nil
+}
@@ -1,195 +1,330 @@
 package lexer
 
 import (
-	"bufio"
-	"errors"
 	"fmt"
+	"io"
 	"orth/cmd/core/orth_debug"
 	orth_types "orth/cmd/pkg/types"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 )
 
-func ppDefineDirective(line string) (string, string) {
-	const directive = "define"
-	name := ""
-	for i := len(directive) + 2; i < len(line) && line[i] != ' '; i++ {
-		name += string(line[i])
-	}
-	value := ""
-	for i := len(directive) + len(name) + 3; i < len(line); i++ {
-		value += string(line[i])
-	}
-	return strings.TrimSpace(name), strings.TrimSpace(value)
-}
+const (
+	directiveDefine  = "@define"
+	directiveUndef   = "@undef"
+	directiveInclude = "@include"
+	directiveMacro   = "@macro"
+	directiveEndM    = "@endmacro"
+	directivePragma  = "@pragma"
+	directiveImport  = "@import"
+)
 
-func preProccessFile(includeFile string, parsedFiles chan orth_types.File[string]) {
-	file, _ := os.Open(includeFile)
-	// looks up for a file with same name on the include paths provided by the programmer
-	if file == nil && *orth_debug.I != "" {
-		paths := strings.Split(*orth_debug.I, ",")
-		for i := 0; i < len(paths); i++ {
-			paths[i] = strings.Trim(paths[i], " ")
-			paths[i] = path.Join(paths[i], includeFile)
+// preprocessor expands the directives of a program and of every file it includes,
+// defines being shared by all of them from the point they are declared onward. Every file is only included once
+type preprocessor struct {
+	defines    map[string][]orth_types.StringEnum
+	macros     map[string]*macro
+	expanding  []string
+	expansions int
+	including  []string
+	included   map[string]bool
+	modules    map[string]*module
+	aliases    map[string]*module
+	files      []orth_types.File[orth_types.SliceOf[orth_types.StringEnum]]
+}
 
-			if _, err := os.Stat(paths[i]); errors.Is(err, os.ErrNotExist) {
-				continue
+// resolveInclude looks up for `includeFile` relative to the file that includes it and then on the include paths provided by the programmer,
+// in the order they were given. The file of the program is looked up as given
+func resolveInclude(includeFile string, include *orth_types.StringEnum) (string, bool) {
+	candidates := []string{includeFile}
+	if include != nil {
+		candidates = []string{filepath.Join(filepath.Dir(include.File), includeFile)}
+		if *orth_debug.I != "" {
+			for _, includePath := range strings.Split(*orth_debug.I, ",") {
+				candidates = append(candidates, filepath.Join(strings.TrimSpace(includePath), includeFile))
 			}
-			file, _ = os.Open(paths[i])
 		}
 	}
-	if file == nil {
-		fmt.Fprint(os.Stderr, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_15, includeFile))
-		os.Exit(1)
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			// paths are kept with forward slashes, so they print the same on every system
+			return filepath.ToSlash(candidate), true
+		}
 	}
+	return "", false
+}
 
+func readSource(sourcePath string) (string, error) {
+	file, err := os.Open(sourcePath)
+	if err != nil {
+		return "", err
+	}
 	defer file.Close()
 
-	var rawFile string
-	source := orth_types.File[string]{
-		Name:      includeFile,
-		CodeBlock: rawFile,
+	source, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
 	}
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		rawFile = fmt.Sprintf("%s %s", rawFile, line)
-		source.UpdateCodeReference(rawFile)
-
-		if len(line) <= 0 || !strings.HasPrefix(line, "@") {
-			continue
-		}
-		var directive string
-
-		for i := 1; i < len(line) && line[i] != ' '; i++ {
-			directive += string(line[i])
-		}
-
-		switch directive {
-		case "define":
-			name, value := ppDefineDirective(line)
-			rawFile = strings.Replace(rawFile, fmt.Sprintf("@define %s %s", name, value), "", -1)
-			rawFile = strings.ReplaceAll(rawFile, name, value)
-
-			source.UpdateCodeReference(rawFile)
-		case "include":
-			includeFile := ""
-			for i := len(directive) + 2; i < len(line) && line[i] != ' '; i++ {
-				includeFile += string(line[i])
-			}
-			includeFile = strings.ReplaceAll(includeFile, `"`, "")
-			includeFile = strings.TrimSpace(includeFile)
-
-			rawFile = strings.Replace(rawFile, fmt.Sprintf(`@include "%s"`, includeFile), "", -1)
-			source.UpdateCodeReference(rawFile)
+	return string(source), nil
+}
 
-			filesToParse := make(chan orth_types.File[string])
+// splitDirective returns the tokens on the same line of the directive at `i`
+func splitDirective(tokens []orth_types.StringEnum, i int) []orth_types.StringEnum {
+	end := i + 1
+	for end < len(tokens) && tokens[end].Index == tokens[i].Index {
+		end++
+	}
+	return tokens[i+1 : end]
+}
 
-			go preProccessFile(includeFile, filesToParse)
+// isDirective checks if the token at `i` is a directive, which must be the first token of its line
+func isDirective(tokens []orth_types.StringEnum, i int) bool {
+	return strings.HasPrefix(tokens[i].Content.Token, "@") &&
+		(i == 0 || tokens[i-1].Index != tokens[i].Index)
+}
 
-			for file := range filesToParse {
-				parsedFiles <- file
-			}
-		default:
-			fmt.Printf("unknow directive found: %q is not recognized as an internal or external directive\n", directive)
-			os.Exit(2)
+// expand replaces the defines within `tokens`, the tokens of a define taking the position of the replaced name
+func (p *preprocessor) expand(tokens []orth_types.StringEnum) []orth_types.StringEnum {
+	expanded := make([]orth_types.StringEnum, 0, len(tokens))
+	for _, token := range tokens {
+		value, ok := p.defines[token.Content.Token]
+		if !ok || token.Content.Kind == orth_types.TokenString {
+			expanded = append(expanded, token)
+			continue
+		}
+		for _, v := range value {
+			v.File = token.File
+			v.Index = token.Index
+			v.Content.Index = token.Content.Index
+			v.IncludedFrom = token.IncludedFrom
+			expanded = append(expanded, v)
 		}
 	}
-
-	parsedFiles <- source
-
-	close(parsedFiles)
+	return expanded
 }
 
-func LoadProgramFromFile(path string) []orth_types.File[string] {
-	// removePathToFile := regexp.MustCompile(`((\.\.\/|\.\/)+|("))`)
-	// path = removePathToFile.ReplaceAllString(path, "")
-	filesParsed := make(chan orth_types.File[string])
-	go preProccessFile(path, filesParsed)
-
-	files := make([]orth_types.File[string], 0)
-
-	for file := range filesParsed {
-		files = append(files, file)
+func (p *preprocessor) defineDirective(directive orth_types.StringEnum, args []orth_types.StringEnum) error {
+	if len(args) == 0 {
+		return orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directiveDefine, "expected a name", directive.File, directive.Index, directive.Content.Index))
 	}
+	name := args[0]
+	if _, ok := p.defines[name.Content.Token]; ok {
+		return orth_debug.TokenError(name, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_02, "DEFINE", name.Content.Token, name.File, name.Index, name.Content.Index))
+	}
+	// the value is expanded right away, so it only sees the defines declared above it
+	p.defines[name.Content.Token] = p.expand(args[1:])
+	return nil
+}
 
-	return files
+func (p *preprocessor) undefDirective(directive orth_types.StringEnum, args []orth_types.StringEnum) error {
+	if len(args) != 1 {
+		return orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directiveUndef, "expected a single name", directive.File, directive.Index, directive.Content.Index))
+	}
+	delete(p.defines, args[0].Content.Token)
+	return nil
 }
 
-// LexFile receives a pure text program then
-// separate and enumerates all tokens present within the provided program
-func LexFile(programFiles []orth_types.File[string]) []orth_types.File[orth_types.SliceOf[orth_types.StringEnum]] {
-	lexedFiles := make([]orth_types.File[orth_types.SliceOf[orth_types.StringEnum]], 0)
+func (p *preprocessor) includeDirective(directive orth_types.StringEnum, args []orth_types.StringEnum) error {
+	if len(args) != 1 || args[0].Content.Kind != orth_types.TokenString {
+		return orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directiveInclude, "expected a file name between quotes", directive.File, directive.Index, directive.Content.Index))
+	}
+	includeFile := strings.TrimSpace(strings.Trim(args[0].Content.Token, `"`))
+	return p.preProccessFile(includeFile, &directive)
+}
 
-	for _, file := range programFiles {
-		pLines := strings.Split(file.CodeBlock, "\r\n")
-		lines := make([]orth_types.StringEnum, 0)
+// pragmaDirective accepts `@pragma once`, which is what every file does already
+func (p *preprocessor) pragmaDirective(directive orth_types.StringEnum, args []orth_types.StringEnum) error {
+	if len(args) != 1 || args[0].Content.Token != "once" {
+		return orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directivePragma, "the only pragma is 'once'", directive.File, directive.Index, directive.Content.Index))
+	}
+	return nil
+}
 
-		for lineNumber, line := range pLines {
-			if len(line) == 0 {
+// process expands the defines, macros and directives within `tokens`
+func (p *preprocessor) process(tokens []orth_types.StringEnum) ([]orth_types.StringEnum, error) {
+	processed := make([]orth_types.StringEnum, 0, len(tokens))
+	conditionals := make([]conditional, 0)
+	for i := 0; i < len(tokens); i++ {
+		if !isDirective(tokens, i) {
+			if skipping(conditionals) {
 				continue
 			}
-			enumeration := make(chan orth_types.Vec2DString)
-
-			go EnumerateLine(line, enumeration)
-
-			for enumeratedLine := range enumeration {
-				vec2d := orth_types.StringEnum{
-					Index:   lineNumber + 1,
-					Content: enumeratedLine,
+			if m, ok := p.macroAt(tokens[i]); ok {
+				expanded, consumed, err := p.expandMacro(m, tokens, i)
+				if err != nil {
+					return nil, err
 				}
-				lines = append(lines, vec2d)
+				processed = append(processed, expanded...)
+				i += consumed - 1
+				continue
 			}
+			for _, token := range p.expand(tokens[i : i+1]) {
+				qualified, err := p.qualify(token)
+				if err != nil {
+					return nil, err
+				}
+				processed = append(processed, qualified)
+			}
+			continue
+		}
+		directive := tokens[i]
+		args := splitDirective(tokens, i)
+		i += len(args)
+
+		if isConditionalDirective(directive.Content.Token) {
+			var err error
+			if conditionals, err = p.conditionalDirective(conditionals, directive, args); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// the other directives of a skipped branch are ignored, unknown ones included
+		if skipping(conditionals) {
+			continue
 		}
 
-		lexedFiles = append(lexedFiles, orth_types.File[orth_types.SliceOf[orth_types.StringEnum]]{
-			Name: file.Name,
-			CodeBlock: orth_types.SliceOf[orth_types.StringEnum]{
-				Slice: &lines,
-			},
-		})
+		var err error
+		switch directive.Content.Token {
+		case directiveDefine:
+			err = p.defineDirective(directive, args)
+		case directiveUndef:
+			err = p.undefDirective(directive, args)
+		case directiveInclude:
+			err = p.includeDirective(directive, args)
+		case directivePragma:
+			err = p.pragmaDirective(directive, args)
+		case directiveImport:
+			err = p.importDirective(directive, args)
+		case directiveMacro:
+			var consumed int
+			consumed, err = p.macroDirective(directive, args, tokens[i+1:])
+			i += consumed
+		default:
+			err = orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_29, directive.Content.Token, directive.File, directive.Index, directive.Content.Index))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(conditionals) != 0 {
+		open := conditionals[len(conditionals)-1].at
+		return nil, orth_debug.TokenError(open, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, open.Content.Token, fmt.Sprintf("it is never closed by %s", directiveEndif), open.File, open.Index, open.Content.Index))
 	}
-	return lexedFiles
+	return processed, nil
 }
 
-// findCol separates the tokens in a `line` starting at `start` by executing a predicate
-func findCol(line string, start int, predicate func(string) bool) int {
-	for start < len(line) && !predicate(string(line[start])) {
-		start++
+// includeChain lists the files being included when reaching `include`, from the file of the program
+func includeChain(include *orth_types.StringEnum) []string {
+	chain := make([]string, 0)
+	for ; include != nil; include = include.IncludedFrom {
+		chain = append([]string{include.File}, chain...)
 	}
-	return start
+	return chain
 }
 
-// EnumerateLine receives a single line and parses and enumerates
-// all tokens in that line feeding the `enumeration` chan
-func EnumerateLine(line string, enumeration chan<- orth_types.Vec2DString) {
-	line = strings.Split(line, "#")[0]
-	col := findCol(line, 0, func(s string) bool {
-		return s != " "
-	})
+// locateFile resolves the file of `directive`, failing when it is already being preprocessed, returning its path and the key identifying it
+func (p *preprocessor) locateFile(includeFile string, include *orth_types.StringEnum, directive string) (string, string, error) {
+	sourcePath, ok := resolveInclude(includeFile, include)
+	if !ok {
+		if include == nil {
+			return "", "", orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_15, includeFile)
+		}
+		return "", "", orth_debug.TokenError(*include, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directive, fmt.Sprintf("could not find %q on paths", includeFile), include.File, include.Index, include.Content.Index))
+	}
+	key, err := filepath.Abs(sourcePath)
+	if err != nil {
+		return "", "", err
+	}
 
-	continueSearch := true
+	for i, including := range p.including {
+		if including == key {
+			chain := append(includeChain(include)[i:], sourcePath)
+			return "", "", orth_debug.TokenError(*include, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_33, strings.Join(chain, " -> "), include.File, include.Index, include.Content.Index))
+		}
+	}
+	return sourcePath, key, nil
+}
 
-	for col < len(line) {
-		colEnd := findCol(line, col, func(s string) bool {
-			if s == "\"" {
-				continueSearch = !continueSearch
-			}
-			if !continueSearch {
-				return false
-			}
-			return s == " "
-		})
+// loadFile scans the file at `sourcePath` and expands its directives with its own import aliases,
+// the names declared by it being mangled when it is the file of module `m`
+func (p *preprocessor) loadFile(sourcePath, key string, include *orth_types.StringEnum, m *module) error {
+	p.included[key] = true
 
-		enumeration <- orth_types.Vec2DString{
-			Index: col,
-			Token: line[col:colEnd],
-		}
+	source, err := readSource(sourcePath)
+	if err != nil {
+		return err
+	}
+	tokens, err := ScanFile(sourcePath, source)
+	if err != nil {
+		return orth_debug.IncludeError(include, err)
+	}
+	for i := range tokens {
+		tokens[i].IncludedFrom = include
+	}
+
+	aliases := p.aliases
+	p.aliases = make(map[string]*module)
+	p.including = append(p.including, key)
+	processed, err := p.process(tokens)
+	p.including = p.including[:len(p.including)-1]
+	p.aliases = aliases
+	if err != nil {
+		return err
+	}
+
+	// the declarations are copied out of the written tokens, which are kept for the documentation
+	written := processed
+	processed, public, err := declarations(processed)
+	if err != nil {
+		return err
+	}
+	if m != nil {
+		processed = m.mangle(processed, public)
+	}
 
-		col = findCol(line, colEnd, func(s string) bool {
-			return s != " "
-		})
+	p.files = append(p.files, orth_types.File[orth_types.SliceOf[orth_types.StringEnum]]{
+		Name: sourcePath,
+		CodeBlock: orth_types.SliceOf[orth_types.StringEnum]{
+			Slice: &processed,
+		},
+		Source: orth_types.SliceOf[orth_types.StringEnum]{
+			Slice: &written,
+		},
+	})
+	return nil
+}
+
+// preProccessFile scans `includeFile` and expands its directives, `include` being the directive that includes it
+// or nil for the file of the program. Included files are added to the program before the file that includes them
+func (p *preprocessor) preProccessFile(includeFile string, include *orth_types.StringEnum) error {
+	sourcePath, key, err := p.locateFile(includeFile, include, directiveInclude)
+	if err != nil {
+		return err
+	}
+	if p.included[key] {
+		return nil
+	}
+	return p.loadFile(sourcePath, key, include, nil)
+}
+
+// LoadProgramFromFile scans the program at `path` and every file it includes, returning their tokens after preprocessing
+func LoadProgramFromFile(path string) ([]orth_types.File[orth_types.SliceOf[orth_types.StringEnum]], error) {
+	p := &preprocessor{
+		defines:  make(map[string][]orth_types.StringEnum),
+		macros:   make(map[string]*macro),
+		included: make(map[string]bool),
+		modules:  make(map[string]*module),
+		aliases:  make(map[string]*module),
+		files:    make([]orth_types.File[orth_types.SliceOf[orth_types.StringEnum]], 0),
+	}
+	if err := p.predefine(); err != nil {
+		return nil, err
+	}
+	if err := p.preProccessFile(path, nil); err != nil {
+		return nil, err
 	}
-	close(enumeration)
+	orth_debug.LogStep(fmt.Sprintf("[CMD] Preprocessed %d file(s)", len(p.files)))
+	return p.files, nil
 }
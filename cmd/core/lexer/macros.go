@@ -0,0 +1,279 @@
+package lexer
+
+import (
+	"fmt"
+	"orth/cmd/core/orth_debug"
+	orth_types "orth/cmd/pkg/types"
+	"regexp"
+	"strings"
+)
+
+var macroParam = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// macro is a block of tokens declared with `@macro name(params...)` and closed by `@endmacro`
+type macro struct {
+	name   string
+	params []string
+	body   []orth_types.StringEnum
+	at     orth_types.StringEnum
+}
+
+// parseMacroHeader reads `name(a, b)` out of the tokens following `@macro`, the parenthesis being optional without params
+func parseMacroHeader(args []orth_types.StringEnum) (string, []string, error) {
+	header := make([]string, 0, len(args))
+	for _, arg := range args {
+		header = append(header, arg.Content.Token)
+	}
+	text := strings.Join(header, "")
+
+	open := strings.Index(text, "(")
+	if open < 0 {
+		if !macroParam.MatchString(text) {
+			return "", nil, fmt.Errorf("invalid macro name %q", text)
+		}
+		return text, nil, nil
+	}
+	name := text[:open]
+	if !macroParam.MatchString(name) {
+		return "", nil, fmt.Errorf("invalid macro name %q", name)
+	}
+	if !strings.HasSuffix(text, ")") {
+		return "", nil, fmt.Errorf("expected ')' closing the params of %q", name)
+	}
+
+	params := make([]string, 0)
+	list := text[open+1 : len(text)-1]
+	if list == "" {
+		return name, params, nil
+	}
+	for _, param := range strings.Split(list, ",") {
+		if !macroParam.MatchString(param) {
+			return "", nil, fmt.Errorf("invalid param %q", param)
+		}
+		for _, declared := range params {
+			if declared == param {
+				return "", nil, fmt.Errorf("param %q is declared twice", param)
+			}
+		}
+		params = append(params, param)
+	}
+	return name, params, nil
+}
+
+// macroDirective declares a macro whose body are the lines in `following` up to `@endmacro`,
+// returning the amount of tokens consumed by the body and `@endmacro`
+func (p *preprocessor) macroDirective(directive orth_types.StringEnum, args []orth_types.StringEnum, following []orth_types.StringEnum) (int, error) {
+	if len(args) == 0 {
+		return 0, orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directiveMacro, "expected a name", directive.File, directive.Index, directive.Content.Index))
+	}
+	name, params, err := parseMacroHeader(args)
+	if err != nil {
+		return 0, orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directiveMacro, err, directive.File, directive.Index, directive.Content.Index))
+	}
+	if _, ok := p.macros[name]; ok {
+		return 0, orth_debug.TokenError(args[0], orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_02, "MACRO", name, args[0].File, args[0].Index, args[0].Content.Index))
+	}
+
+	for end := range following {
+		if !isDirective(following, end) {
+			continue
+		}
+		switch following[end].Content.Token {
+		case directiveMacro:
+			return 0, orth_debug.TokenError(following[end], orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directiveMacro, "macros can not be declared inside of another macro", following[end].File, following[end].Index, following[end].Content.Index))
+		case directiveEndM:
+			endArgs := splitDirective(following, end)
+			if len(endArgs) != 0 {
+				return 0, orth_debug.TokenError(endArgs[0], orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directiveEndM, "unexpected tokens after it", endArgs[0].File, endArgs[0].Index, endArgs[0].Content.Index))
+			}
+			p.macros[name] = &macro{
+				name:   name,
+				params: params,
+				body:   following[:end],
+				at:     args[0],
+			}
+			return end + 1, nil
+		}
+	}
+	return 0, orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directiveMacro, fmt.Sprintf("%q is never closed by %s", name, directiveEndM), directive.File, directive.Index, directive.Content.Index))
+}
+
+// macroAt checks if `token` calls a macro, either by its name or by `name(`
+func (p *preprocessor) macroAt(token orth_types.StringEnum) (*macro, bool) {
+	if token.Content.Kind == orth_types.TokenString {
+		return nil, false
+	}
+	name, _, _ := strings.Cut(token.Content.Token, "(")
+	m, ok := p.macros[name]
+	return m, ok
+}
+
+// splitPunctuation splits a token on the `(`, `)` and `,` outside of string literals, the punctuation included
+func splitPunctuation(token orth_types.StringEnum) []orth_types.StringEnum {
+	pieces := make([]orth_types.StringEnum, 0)
+	text := token.Content.Token
+	add := func(start, end int) {
+		if start == end {
+			return
+		}
+		piece := token
+		piece.Content.Index += start
+		piece.Content.Token = text[start:end]
+		piece.Content.Kind = tokenKind(piece.Content.Token)
+		pieces = append(pieces, piece)
+	}
+
+	start := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '"':
+			for i++; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' {
+					i++
+				}
+			}
+		case '(', ')', ',':
+			add(start, i)
+			add(i, i+1)
+			start = i + 1
+		}
+	}
+	add(start, len(text))
+	return pieces
+}
+
+// macroArgs collects the arguments of the call of `m` at `tokens[i]`, returning them and the amount of tokens consumed.
+// Arguments are split by commas, so the `,` instruction can only be used within parenthesis
+func macroArgs(m *macro, tokens []orth_types.StringEnum, i int) ([][]orth_types.StringEnum, int, error) {
+	use := tokens[i]
+	pieces := splitPunctuation(use)[1:]
+	next := i + 1
+	if len(pieces) == 0 && next < len(tokens) && tokens[next].Index == use.Index && strings.HasPrefix(tokens[next].Content.Token, "(") {
+		pieces = splitPunctuation(tokens[next])
+		next++
+	}
+	if len(pieces) == 0 || pieces[0].Content.Token != "(" {
+		if len(m.params) != 0 {
+			return nil, 0, orth_debug.TokenError(use, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_32, m.name, fmt.Sprintf("expected %d argument(s) between parenthesis", len(m.params)), use.File, use.Index, use.Content.Index))
+		}
+		if len(pieces) != 0 {
+			return nil, 0, orth_debug.TokenError(use, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_32, m.name, fmt.Sprintf("unexpected %q after its name", pieces[0].Content.Token), use.File, use.Index, use.Content.Index))
+		}
+		return nil, 1, nil
+	}
+
+	args := [][]orth_types.StringEnum{{}}
+	depth := 0
+	for {
+		if len(pieces) == 0 {
+			if next >= len(tokens) {
+				return nil, 0, orth_debug.TokenError(use, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_32, m.name, "expected ')' closing its arguments", use.File, use.Index, use.Content.Index))
+			}
+			pieces = splitPunctuation(tokens[next])
+			next++
+		}
+		piece := pieces[0]
+		pieces = pieces[1:]
+
+		switch piece.Content.Token {
+		case "(":
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ")":
+			depth--
+			if depth == 0 {
+				if len(pieces) != 0 {
+					return nil, 0, orth_debug.TokenError(use, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_32, m.name, fmt.Sprintf("unexpected %q after its arguments", pieces[0].Content.Token), use.File, use.Index, use.Content.Index))
+				}
+				if len(args) == 1 && len(args[0]) == 0 {
+					args = args[:0]
+				}
+				if len(args) != len(m.params) {
+					return nil, 0, orth_debug.TokenError(use, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_32, m.name, fmt.Sprintf("expected %d argument(s) but got %d", len(m.params), len(args)), use.File, use.Index, use.Content.Index))
+				}
+				return args, next - i, nil
+			}
+		case ",":
+			if depth == 1 {
+				args = append(args, []orth_types.StringEnum{})
+				continue
+			}
+		}
+		args[len(args)-1] = append(args[len(args)-1], piece)
+	}
+}
+
+// localNames gives an unique name, for this expansion, to every var, const and memory region declared within the body of `m`
+func (p *preprocessor) localNames(m *macro) map[string]string {
+	locals := make(map[string]string)
+	for i := 0; i+1 < len(m.body); i++ {
+		switch m.body[i].Content.Token {
+		case orth_types.StdVar, orth_types.StdConst, orth_types.StdMemory:
+			name := m.body[i+1]
+			if name.Content.Kind == orth_types.TokenIdentifier {
+				locals[name.Content.Token] = fmt.Sprintf("%s__%s_%d", name.Content.Token, m.name, p.expansions)
+			}
+		}
+	}
+	return locals
+}
+
+// expandMacro replaces the call of `m` at `tokens[i]` with its body, returning the expanded tokens and the amount of tokens of the call
+func (p *preprocessor) expandMacro(m *macro, tokens []orth_types.StringEnum, i int) ([]orth_types.StringEnum, int, error) {
+	use := tokens[i]
+	for depth, expanding := range p.expanding {
+		if expanding == m.name {
+			chain := strings.Join(append(p.expanding[depth:], m.name), " -> ")
+			return nil, 0, orth_debug.TokenError(use, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_31, m.name, chain, use.File, use.Index, use.Content.Index))
+		}
+	}
+
+	args, consumed, err := macroArgs(m, tokens, i)
+	if err != nil {
+		return nil, 0, err
+	}
+	p.expansions++
+	locals := p.localNames(m)
+	expansion := &orth_types.MacroExpansion{
+		Macro:      m.name,
+		Definition: m.at,
+		Use:        use,
+	}
+
+	body := make([]orth_types.StringEnum, 0, len(m.body))
+	for _, token := range m.body {
+		token.ExpandedFrom = expansion
+		if token.Content.Kind != orth_types.TokenString {
+			if local, ok := locals[token.Content.Token]; ok {
+				token.Content.Token = local
+			}
+			if param := indexOf(m.params, token.Content.Token); param >= 0 {
+				// the arguments take the place of the param so the lines of the body are kept
+				for _, arg := range args[param] {
+					arg.File, arg.Index, arg.Content.Index = token.File, token.Index, token.Content.Index
+					arg.IncludedFrom = token.IncludedFrom
+					arg.ExpandedFrom = expansion
+					body = append(body, arg)
+				}
+				continue
+			}
+		}
+		body = append(body, token)
+	}
+
+	p.expanding = append(p.expanding, m.name)
+	expanded, err := p.process(body)
+	p.expanding = p.expanding[:len(p.expanding)-1]
+	return expanded, consumed, err
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
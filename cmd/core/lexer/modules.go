@@ -0,0 +1,222 @@
+package lexer
+
+import (
+	"fmt"
+	"orth/cmd/core/orth_debug"
+	orth_types "orth/cmd/pkg/types"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// moduleSeparator splits the alias of an imported module from a name it declares. Ex: geo::area
+const moduleSeparator = "::"
+
+// module is a file loaded by `@import`, whose procs and consts are renamed so they do not clash with the ones of other files
+type module struct {
+	name   string
+	order  int
+	names  map[string]string
+	public map[string]bool
+}
+
+// blocks follows the contexts the parser opens for the tokens of a file, keeping the names
+// declared by `var` and `const` within each of them, which hide the declarations of the module
+type blocks struct {
+	locals []map[string]bool
+	// within an `enum` or `asm` block, which is not made of instructions
+	raw bool
+}
+
+// step moves past `tokens[i]`, returning false for the tokens of the blocks that are not made of instructions
+func (b *blocks) step(tokens []orth_types.StringEnum, i int) bool {
+	token := tokens[i].Content
+	if b.raw {
+		b.raw = token.Token != orth_types.StdEND
+		return false
+	}
+	if token.Kind == orth_types.TokenString {
+		return true
+	}
+
+	switch token.Token {
+	case orth_types.StdEnum, orth_types.StdAsm:
+		b.raw = true
+		return false
+	case orth_types.StdProc, orth_types.StdIf, orth_types.StdDo:
+		b.locals = append(b.locals, make(map[string]bool))
+	case orth_types.StdElse:
+		// else is a sibling of its if, which does not share its locals
+		if len(b.locals) > 0 {
+			b.locals[len(b.locals)-1] = make(map[string]bool)
+		}
+	case orth_types.StdEND:
+		if len(b.locals) > 0 {
+			b.locals = b.locals[:len(b.locals)-1]
+		}
+	case orth_types.StdVar, orth_types.StdConst:
+		if len(b.locals) > 0 && i+1 < len(tokens) {
+			b.locals[len(b.locals)-1][tokens[i+1].Content.Token] = true
+		}
+	}
+	return true
+}
+
+// global checks if the tokens stepped so far are out of every block
+func (b *blocks) global() bool {
+	return len(b.locals) == 0
+}
+
+// hides checks if `name` is declared by one of the blocks around the last token
+func (b *blocks) hides(name string) bool {
+	for _, locals := range b.locals {
+		if locals[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// mangle renames the references to the procs and consts declared by the module, `declared` telling which of them are public.
+// Names are scoped the way contexts are named, "c?_module_<module>_<n>$_<name>", the locals of
+// procs and blocks that share a name with them being left untouched
+func (m *module) mangle(tokens []orth_types.StringEnum, declared map[string]bool) []orth_types.StringEnum {
+	m.names = make(map[string]string, len(declared))
+	m.public = declared
+	scope := orth_types.ModuleScope(m.name, m.order)
+	for name := range declared {
+		m.names[name] = fmt.Sprintf("%s_%s", scope, name)
+	}
+
+	var b blocks
+	for i, token := range tokens {
+		if !b.step(tokens, i) || token.Content.Kind == orth_types.TokenString {
+			continue
+		}
+		name, isAddress := strings.CutPrefix(token.Content.Token, orth_types.StdProcAddress)
+		mangled, ok := m.names[name]
+		if !ok || b.hides(name) {
+			continue
+		}
+		if isAddress {
+			mangled = orth_types.StdProcAddress + mangled
+		}
+		tokens[i].Content.Token = mangled
+	}
+	return tokens
+}
+
+// declarations removes the `pub` modifiers out of `tokens`, returning the procs and consts declared out of
+// every block mapped to whenever they are public
+func declarations(tokens []orth_types.StringEnum) ([]orth_types.StringEnum, map[string]bool, error) {
+	declared := make(map[string]bool)
+	stripped := make([]orth_types.StringEnum, 0, len(tokens))
+	var b blocks
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		public := false
+		if token.Content.Token == orth_types.StdPub && token.Content.Kind == orth_types.TokenKeyword {
+			if i+1 >= len(tokens) || (tokens[i+1].Content.Token != orth_types.StdProc && tokens[i+1].Content.Token != orth_types.StdConst) {
+				return nil, nil, orth_debug.TokenError(token, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_27, orth_types.StdPub, "expected a `proc` or `const` after it", token.File, token.Index, token.Content.Index))
+			}
+			public = true
+			i++
+			doc := token.Doc
+			token = tokens[i]
+			token.Doc = doc
+		}
+		global := b.global()
+		if b.step(tokens, i) && global && (token.Content.Token == orth_types.StdProc || token.Content.Token == orth_types.StdConst) && i+1 < len(tokens) {
+			name := tokens[i+1].Content.Token
+			declared[name] = declared[name] || public
+		}
+		stripped = append(stripped, token)
+	}
+	return stripped, declared, nil
+}
+
+// qualify replaces a reference to a name of an imported module, `alias::name`, with its mangled name
+func (p *preprocessor) qualify(token orth_types.StringEnum) (orth_types.StringEnum, error) {
+	if token.Content.Kind == orth_types.TokenString {
+		return token, nil
+	}
+	reference, isAddress := strings.CutPrefix(token.Content.Token, orth_types.StdProcAddress)
+	alias, name, ok := strings.Cut(reference, moduleSeparator)
+	if !ok {
+		return token, nil
+	}
+
+	invalid := func(reason string) error {
+		return orth_debug.TokenError(token, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_34, token.Content.Token, reason, token.File, token.Index, token.Content.Index))
+	}
+	m, ok := p.aliases[alias]
+	if !ok {
+		return token, invalid(fmt.Sprintf("no module was imported as %q", alias))
+	}
+	mangled, ok := m.names[name]
+	if !ok {
+		return token, invalid(fmt.Sprintf("module %q does not declare %q", m.name, name))
+	}
+	if !m.public[name] {
+		return token, invalid(fmt.Sprintf("%q is not public in module %q", name, m.name))
+	}
+
+	if isAddress {
+		mangled = orth_types.StdProcAddress + mangled
+	}
+	token.Content.Token = mangled
+	token.Content.Kind = orth_types.TokenIdentifier
+	return token, nil
+}
+
+var notWord = regexp.MustCompile(`\W`)
+
+// moduleName names the module of `sourcePath` after its file, numbering the ones whose files share a name
+func (p *preprocessor) moduleName(sourcePath string) string {
+	base := notWord.ReplaceAllString(strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath)), "_")
+	name := base
+	for n := 2; ; n++ {
+		taken := false
+		for _, m := range p.modules {
+			taken = taken || m.name == name
+		}
+		if !taken {
+			return name
+		}
+		name = fmt.Sprintf("%s_%d", base, n)
+	}
+}
+
+// importDirective loads the module of `@import "file" as alias`, a module being loaded only once no matter how many files import it
+func (p *preprocessor) importDirective(directive orth_types.StringEnum, args []orth_types.StringEnum) error {
+	if len(args) != 3 || args[0].Content.Kind != orth_types.TokenString || args[1].Content.Token != "as" || !macroParam.MatchString(args[2].Content.Token) {
+		return orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directiveImport, `expected '@import "file" as alias'`, directive.File, directive.Index, directive.Content.Index))
+	}
+	alias := args[2]
+	if _, ok := p.aliases[alias.Content.Token]; ok {
+		return orth_debug.TokenError(alias, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_02, "IMPORT", alias.Content.Token, alias.File, alias.Index, alias.Content.Index))
+	}
+
+	importFile := strings.TrimSpace(strings.Trim(args[0].Content.Token, `"`))
+	if filepath.Ext(importFile) == "" {
+		importFile += "." + orth_types.FileType
+	}
+	sourcePath, key, err := p.locateFile(importFile, &directive, directiveImport)
+	if err != nil {
+		return err
+	}
+
+	m, ok := p.modules[key]
+	if !ok {
+		if p.included[key] {
+			return orth_debug.TokenError(directive, orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_30, directiveImport, fmt.Sprintf("%q was already included as a plain file", sourcePath), directive.File, directive.Index, directive.Content.Index))
+		}
+		m = &module{name: p.moduleName(sourcePath), order: len(p.modules)}
+		p.modules[key] = m
+		if err := p.loadFile(sourcePath, key, &directive, m); err != nil {
+			return err
+		}
+	}
+	p.aliases[alias.Content.Token] = m
+	return nil
+}
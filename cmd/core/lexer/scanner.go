@@ -0,0 +1,251 @@
+package lexer
+
+import (
+	"orth/cmd/core/orth_debug"
+	orth_types "orth/cmd/pkg/types"
+	"strconv"
+	"strings"
+)
+
+const (
+	blockCommentOpen  = "#["
+	blockCommentClose = "]#"
+	docCommentStart   = "##"
+)
+
+var keywords map[string]bool
+
+func init() {
+	keywords = make(map[string]bool)
+	for _, keyword := range []string{
+		// keywords
+		orth_types.StdPlus, orth_types.StdMinus, orth_types.StdMult, orth_types.StdDiv, orth_types.StdEquals,
+		orth_types.StdNotEquals, orth_types.StdLowerThan, orth_types.StdGreaterThan, orth_types.StdMod, orth_types.StdEND,
+		orth_types.StdParam, orth_types.StdMem, orth_types.StdMemory, orth_types.StdType, orth_types.StdConst,
+		orth_types.StdVar, orth_types.StdHold, orth_types.StdProc, orth_types.StdIn, orth_types.StdIf,
+		orth_types.StdElse, orth_types.StdOver, orth_types.Std2Dup, orth_types.StdDup, orth_types.StdWhile,
+		orth_types.StdLeftShift, orth_types.StdRightShift, orth_types.StdLogicalAnd, orth_types.StdLogicalOr, orth_types.StdDo,
+		orth_types.StdDrop, orth_types.StdSwap, orth_types.StdStore, orth_types.StdLoad, orth_types.StdCall,
+		orth_types.StdLoadAndStay, orth_types.StdInvoke, orth_types.StdProcOutParams, orth_types.StdProcInParams, orth_types.StdBitwise,
+		orth_types.StdAssign, orth_types.StdArrayOpen, orth_types.StdArrayClose, orth_types.StdIndexLoad, orth_types.StdIndexStore,
+		orth_types.StdCast, orth_types.StdEnum, orth_types.StdEnumCount, orth_types.StdSizeOf, orth_types.StdProcAddress,
+		orth_types.StdCallIndirect, orth_types.StdAsm, orth_types.StdExtern, orth_types.StdWith, orth_types.StdCli,
+		orth_types.StdEnv, orth_types.StdOut, orth_types.StdAssert, orth_types.StdPub,
+		// builtin functions
+		orth_types.StdPutUint, orth_types.StdPutUintPad, orth_types.StdPutInt, orth_types.StdPutHex, orth_types.StdPutBin,
+		orth_types.StdPutBool, orth_types.StdPutStr, orth_types.StdSetNumber, orth_types.StdSetStr, orth_types.StdDumpMem,
+		orth_types.StdPutChar, orth_types.StdDeref, orth_types.StdExit, orth_types.StdAlloc, orth_types.StdFree,
+		orth_types.StdFOpen, orth_types.StdFRead, orth_types.StdFWrite, orth_types.StdFClose, orth_types.StdFSize,
+		orth_types.StdUnlink, orth_types.StdPutStrFd, orth_types.StdEWrite, orth_types.StdGetChar, orth_types.StdReadLine,
+		orth_types.StdReadInt, orth_types.StdGetEnv, orth_types.StdSyscall0, orth_types.StdSyscall1, orth_types.StdSyscall2,
+		orth_types.StdSyscall3, orth_types.StdSyscall4, orth_types.StdSyscall5, orth_types.StdSyscall6,
+	} {
+		keywords[keyword] = true
+	}
+}
+
+// scanner walks over the source of a single file keeping track of the current line and column
+type scanner struct {
+	file   string
+	source string
+	offset int
+	line   int
+	col    int
+}
+
+func (s *scanner) peek() byte {
+	return s.source[s.offset]
+}
+
+func (s *scanner) done() bool {
+	return s.offset >= len(s.source)
+}
+
+// advance moves past the current byte, "\r\n" being moved over as a single line break
+func (s *scanner) advance() {
+	switch s.peek() {
+	case '\r':
+		if s.offset+1 < len(s.source) && s.source[s.offset+1] == '\n' {
+			s.offset++
+		}
+		fallthrough
+	case '\n':
+		s.line++
+		s.col = 0
+	default:
+		s.col++
+	}
+	s.offset++
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\f' || c == '\v'
+}
+
+func isLineBreak(c byte) bool {
+	return c == '\n' || c == '\r'
+}
+
+// skipComment moves to the end of the line of a `#` comment
+func (s *scanner) skipComment() {
+	for !s.done() && !isLineBreak(s.peek()) {
+		s.advance()
+	}
+}
+
+// startsWith checks if the source at the current position starts with `prefix`
+func (s *scanner) startsWith(prefix string) bool {
+	return strings.HasPrefix(s.source[s.offset:], prefix)
+}
+
+// skipBlockComment moves past a `#[ ... ]#` comment, which can span over multiple lines and be nested
+func (s *scanner) skipBlockComment() error {
+	line, col := s.line, s.col
+	depth := 0
+	for !s.done() {
+		switch {
+		case s.startsWith(blockCommentOpen):
+			depth++
+		case s.startsWith(blockCommentClose):
+			depth--
+		default:
+			s.advance()
+			continue
+		}
+		s.advance()
+		s.advance()
+		if depth == 0 {
+			return nil
+		}
+	}
+	return orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_35, s.file, line, col)
+}
+
+// docComment reads the text of a `##` comment, without the space that usually follows `##`
+func (s *scanner) docComment() string {
+	s.advance()
+	s.advance()
+	start := s.offset
+	s.skipComment()
+	return strings.TrimRight(strings.TrimPrefix(s.source[start:s.offset], " "), " \t")
+}
+
+// scanString moves past a string literal, `\"` and `\\` being escapes that do not close it.
+// String literals can not span over multiple lines
+func (s *scanner) scanString() error {
+	line, col := s.line, s.col
+	s.advance()
+	for !s.done() && !isLineBreak(s.peek()) {
+		switch s.peek() {
+		case '\\':
+			s.advance()
+			if s.done() || isLineBreak(s.peek()) {
+				continue
+			}
+		case '"':
+			s.advance()
+			return nil
+		}
+		s.advance()
+	}
+	return orth_debug.BuildErrorMessage(orth_debug.ORTH_ERR_28, s.file, line, col)
+}
+
+// scanToken reads a token up to the next blank, line break or comment, string literals included
+func (s *scanner) scanToken() (orth_types.StringEnum, error) {
+	line, col, start := s.line, s.col, s.offset
+	for !s.done() && !isBlank(s.peek()) && !isLineBreak(s.peek()) && s.peek() != '#' {
+		if s.peek() == '"' {
+			if err := s.scanString(); err != nil {
+				return orth_types.StringEnum{}, err
+			}
+			continue
+		}
+		s.advance()
+	}
+
+	token := s.source[start:s.offset]
+	return orth_types.StringEnum{
+		File:  s.file,
+		Index: line,
+		Content: orth_types.Vec2DString{
+			Index: col,
+			Token: token,
+			Kind:  tokenKind(token),
+		},
+	}, nil
+}
+
+// tokenKind classifies a token already split by the scanner
+func tokenKind(token string) orth_types.TokenKind {
+	switch {
+	case token[0] == '"':
+		return orth_types.TokenString
+	case isNumber(token):
+		return orth_types.TokenNumber
+	case token == orth_types.StdPtr ||
+		orth_types.GlobalTypes[orth_types.INTS][token] != "" ||
+		orth_types.GlobalTypes[orth_types.FLOATS][token] != "" ||
+		orth_types.GlobalTypes[orth_types.STRING][token] != "" ||
+		orth_types.GlobalTypes[orth_types.BOOL][token] != "" ||
+		orth_types.GlobalTypes[orth_types.VOID][token] != "" ||
+		orth_types.GlobalTypes[orth_types.RNT][token] != "":
+		return orth_types.TokenType
+	case keywords[token]:
+		return orth_types.TokenKeyword
+	default:
+		return orth_types.TokenIdentifier
+	}
+}
+
+// isNumber checks if a token is an integer or a float literal. Ex: 10, -3, 0x1f, 2.5
+func isNumber(token string) bool {
+	digits := token
+	if digits[0] == '-' || digits[0] == '+' {
+		digits = digits[1:]
+	}
+	if len(digits) == 0 || !(digits[0] >= '0' && digits[0] <= '9' || digits[0] == '.') {
+		return false
+	}
+	if _, err := strconv.ParseInt(token, 0, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseFloat(token, 64)
+	return err == nil
+}
+
+// ScanFile splits the source of a file into typed tokens, skipping blanks and comments.
+// The lines of `##` comments are kept in the `Doc` of the token that follows them. Lines start at 1 and columns at 0
+func ScanFile(name, source string) ([]orth_types.StringEnum, error) {
+	s := &scanner{
+		file:   name,
+		source: source,
+		line:   1,
+	}
+
+	tokens := make([]orth_types.StringEnum, 0)
+	doc := make([]string, 0)
+	for !s.done() {
+		switch c := s.peek(); {
+		case isBlank(c) || isLineBreak(c):
+			s.advance()
+		case s.startsWith(blockCommentOpen):
+			if err := s.skipBlockComment(); err != nil {
+				return nil, err
+			}
+		case s.startsWith(docCommentStart):
+			doc = append(doc, s.docComment())
+		case c == '#':
+			s.skipComment()
+		default:
+			token, err := s.scanToken()
+			if err != nil {
+				return nil, err
+			}
+			token.Doc = strings.Join(doc, "\n")
+			doc = doc[:0]
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
@@ -15,22 +15,45 @@ import (
 	"strings"
 )
 
+// docCommand writes the reference of the `##` comments of a program instead of compiling it: `orth doc <file_path>`
+const docCommand = "doc"
+
+// jsonDiagnostics is the `-diagnostics` format writing the errors and warnings as JSON
+const jsonDiagnostics = "json"
+
+// isDocCommand checks if the compiler was called as `orth doc <file_path>`
+func isDocCommand() bool {
+	return flag.Arg(0) == docCommand && len(flag.Args()) > 1
+}
+
+// sourceCodePath is the program given to the compiler, following the command when there is one
+func sourceCodePath() string {
+	if isDocCommand() {
+		return flag.Arg(1)
+	}
+	return flag.Arg(0)
+}
+
 func init() {
 	flag.Parse()
-	sourceCodePath := flag.Args()[0]
 
 	if *orth_debug.Help {
 		flag.PrintDefaults()
 	}
 	if len(flag.Args()) < 1 {
 		fmt.Println("Usage: <orth> <file_path>")
+		fmt.Println("       <orth> doc <file_path>")
+		os.Exit(1)
+	}
+	if !strings.HasSuffix(sourceCodePath(), orth_types.FileType) {
+		fmt.Printf("[ERROR] The selected file %q is not of type %q\n", sourceCodePath(), orth_types.FileType)
 		os.Exit(1)
 	}
-	if !strings.HasSuffix(sourceCodePath, orth_types.FileType) {
-		fmt.Printf("[ERROR] The selected file %q is not of type %q\n", sourceCodePath, orth_types.FileType)
+	if *orth_debug.Diagnostics != "text" && *orth_debug.Diagnostics != jsonDiagnostics {
+		fmt.Printf("[ERROR] Unknown diagnostics format %q, expected \"text\" or %q\n", *orth_debug.Diagnostics, jsonDiagnostics)
 		os.Exit(1)
 	}
-	if !*orth_debug.Help && (*orth_debug.Compile == "") {
+	if !*orth_debug.Help && !isDocCommand() && (*orth_debug.Compile == "") {
 		fmt.Println("Error, must select a run option.")
 		flag.PrintDefaults()
 		os.Exit(1)
@@ -38,14 +61,28 @@ func init() {
 }
 
 func main() {
-	sourceCodePath := flag.Args()[0]
-	strProgram := lexer.LoadProgramFromFile(sourceCodePath)
-	lexedFiles := lexer.LexFile(strProgram)
+	lexedFiles, err := lexer.LoadProgramFromFile(sourceCodePath())
+	if err != nil {
+		exitOnErrors(orth_types.Program{Error: []error{err}})
+	}
+	documentation := embedded.CollectDocumentation(lexedFiles)
+
+	if isDocCommand() {
+		reference, err := embedded.RenderDocumentation(documentation, *orth_debug.DocFormat)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Print(reference)
+		os.Exit(0)
+	}
 
 	parsedOperations := make(chan orth_types.Pair[orth_types.Operation, error])
 
 	program := orth_types.Program{
-		Operations: make([]orth_types.Operation, 0),
+		Operations:    make([]orth_types.Operation, 0),
+		MemSize:       *orth_debug.MemSize,
+		Documentation: documentation,
 	}
 
 	go embedded.ParseTokenAsOperation(lexedFiles, parsedOperations)
@@ -54,35 +91,34 @@ func main() {
 	for parsedOperation := range parsedOperations {
 		if parsedOperation.Right != nil {
 			program.Error = append(program.Error, parsedOperation.Right)
-			break
+			continue
 		}
 		parsedOperation.Left = embedded_helpers.LinkVariableToValue(parsedOperation.Left, &analyzerOperations, &program)
 		analyzerOperations = append(analyzerOperations, parsedOperation.Left)
 	}
-
-	if len(program.Error) != 0 {
-		for _, err := range program.Error {
-			fmt.Fprint(os.Stderr, err)
-		}
-		os.Exit(1)
-	}
+	exitOnErrors(program)
 
 	optimizedOperation, warnings := optimizer.AnalyzeAndOptimizeOperations(analyzerOperations)
 	program.Warnings = append(program.Warnings, warnings...)
 	program.Operations = append(program.Operations, optimizedOperation...)
 
-	for _, warning := range program.Warnings {
-		fmt.Println(warning.Message)
+	program = embedded.CrossReferenceBlocks(program)
+	if len(program.Error) == 0 {
+		program, err = embedded.TypeCheckPointers(program)
+		if err != nil {
+			program.Error = append(program.Error, err)
+		}
 	}
+	exitOnErrors(program)
 
-	program, err := embedded.CrossReferenceBlocks(program)
+	// the status of the simulated program is not the one of the compiler, which only stops on runtime errors
 	if *orth_debug.Sim {
-		simulation.SimulateStack(&program)
-	}
-
-	if err != nil {
-		fmt.Fprint(os.Stderr, err)
-		os.Exit(1)
+		status, err := simulation.SimulateStack(&program)
+		if err != nil {
+			program.Error = append(program.Error, err)
+			exitOnErrors(program)
+		}
+		orth_debug.LogStep(fmt.Sprintf("[INFO] Simulated program exited with status %d", status))
 	}
 
 	switch {
@@ -93,10 +129,47 @@ func main() {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
-		embedded.Compile(program, asmTarget)
+		if err := embedded.Compile(program, asmTarget); err != nil {
+			program.Error = append(program.Error, err)
+			exitOnErrors(program)
+		}
 		orth_debug.LogStep("[INFO] Finished compilation.")
 	default:
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
+	writeDiagnostics(program)
+}
+
+// exitOnErrors writes every error found on the program and stops the compiler when there is any
+func exitOnErrors(program orth_types.Program) {
+	if len(program.Error) == 0 {
+		return
+	}
+	writeDiagnostics(program)
+	os.Exit(1)
+}
+
+// writeDiagnostics writes the warnings and errors of the program to stderr, or to the `-diagout` file, rendered as text
+// or, with `-diagnostics=json`, as a JSON object per line
+func writeDiagnostics(program orth_types.Program) {
+	output := os.Stderr
+	if *orth_debug.DiagOut != "" {
+		file, err := os.Create(*orth_debug.DiagOut)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		output = file
+	}
+
+	if *orth_debug.Diagnostics == jsonDiagnostics {
+		if err := orth_debug.WriteJSONDiagnostics(output, program.Error, program.Warnings); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprint(output, orth_debug.RenderDiagnostics(program.Error, program.Warnings, orth_debug.ColorEnabled(output)))
 }
@@ -0,0 +1,213 @@
+package orth_debug
+
+import (
+	"errors"
+	"fmt"
+	orth_types "orth/cmd/pkg/types"
+	"strings"
+)
+
+// Severity tells if a diagnostic stops the compilation
+type Severity uint8
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityNote
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityNote:
+		return "note"
+	}
+	return "error"
+}
+
+// codes names the messages, so a diagnostic can be told apart by the message it was built from
+var codes = map[string]string{
+	ORTH_ERR_01:  "ORTH_ERR_01",
+	ORTH_ERR_02:  "ORTH_ERR_02",
+	ORTH_ERR_03:  "ORTH_ERR_03",
+	ORTH_ERR_04:  "ORTH_ERR_04",
+	ORTH_ERR_05:  "ORTH_ERR_05",
+	ORTH_ERR_06:  "ORTH_ERR_06",
+	ORTH_ERR_07:  "ORTH_ERR_07",
+	ORTH_ERR_08:  "ORTH_ERR_08",
+	ORTH_ERR_09:  "ORTH_ERR_09",
+	ORTH_ERR_10:  "ORTH_ERR_10",
+	ORTH_ERR_11:  "ORTH_ERR_11",
+	ORTH_ERR_12:  "ORTH_ERR_12",
+	ORTH_ERR_13:  "ORTH_ERR_13",
+	ORTH_ERR_14:  "ORTH_ERR_14",
+	ORTH_ERR_15:  "ORTH_ERR_15",
+	ORTH_ERR_16:  "ORTH_ERR_16",
+	ORTH_ERR_17:  "ORTH_ERR_17",
+	ORTH_ERR_18:  "ORTH_ERR_18",
+	ORTH_ERR_19:  "ORTH_ERR_19",
+	ORTH_ERR_20:  "ORTH_ERR_20",
+	ORTH_ERR_21:  "ORTH_ERR_21",
+	ORTH_ERR_22:  "ORTH_ERR_22",
+	ORTH_ERR_23:  "ORTH_ERR_23",
+	ORTH_ERR_24:  "ORTH_ERR_24",
+	ORTH_ERR_25:  "ORTH_ERR_25",
+	ORTH_ERR_26:  "ORTH_ERR_26",
+	ORTH_ERR_27:  "ORTH_ERR_27",
+	ORTH_ERR_28:  "ORTH_ERR_28",
+	ORTH_ERR_29:  "ORTH_ERR_29",
+	ORTH_ERR_30:  "ORTH_ERR_30",
+	ORTH_ERR_31:  "ORTH_ERR_31",
+	ORTH_ERR_32:  "ORTH_ERR_32",
+	ORTH_ERR_33:  "ORTH_ERR_33",
+	ORTH_ERR_34:  "ORTH_ERR_34",
+	ORTH_ERR_35:  "ORTH_ERR_35",
+	ORTH_ERR_36:  "ORTH_ERR_36",
+	ORTH_ERR_37:  "ORTH_ERR_37",
+	ORTH_WARN_01: "ORTH_WARN_01",
+	ORTH_WARN_02: "ORTH_WARN_02",
+}
+
+// Note adds context to a diagnostic, like the macro or the include a token came from
+type Note struct {
+	Message  string
+	Location orth_types.Location
+}
+
+// Diagnostic is an error or warning found on the program. `Location` is empty when the
+// problem is not bound to a token and `Message` has neither the severity nor the location
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	Message  string
+	Location orth_types.Location
+	Notes    []Note
+	// text is the message as it was always printed, followed by the notes on Error
+	text string
+}
+
+// Error prints the diagnostic followed by a line for each note
+func (d *Diagnostic) Error() string {
+	var text strings.Builder
+	text.WriteString(d.text)
+	for _, note := range d.Notes {
+		text.WriteString(BuildMessage("\t%s "+commomFileSpecificationStruct, note.Message, note.Location.File, note.Location.Line, note.Location.Column))
+	}
+	return text.String()
+}
+
+// newDiagnostic builds the diagnostic of `message`. Messages ending with the location of a token
+// take its file, line and colum as their last params
+func newDiagnostic(message string, params ...interface{}) *Diagnostic {
+	d := &Diagnostic{
+		Severity: SeverityError,
+		Code:     codes[message],
+		text:     BuildMessage(message, params...),
+	}
+	if strings.HasPrefix(message, "[WARN]") {
+		d.Severity = SeverityWarning
+	}
+
+	format := message
+	if n := len(params); strings.HasSuffix(message, commomFileSpecificationStruct) && n >= 3 {
+		file, _ := params[n-3].(string)
+		line, _ := params[n-2].(int)
+		column, _ := params[n-1].(int)
+		d.Location = orth_types.Location{File: file, Line: line, Column: column}
+		format = strings.TrimSuffix(message, commomFileSpecificationStruct)
+		params = params[:n-3]
+	}
+	d.Message = trimSeverity(BuildMessage(format, params...))
+	return d
+}
+
+// BuildWarning builds the warning of one of the `ORTH_WARN_XX` messages found at `location`
+func BuildWarning(degree orth_types.WarnDegree, location orth_types.Location, message string, params ...interface{}) orth_types.CompilerMessage {
+	return orth_types.CompilerMessage{
+		Type:     degree,
+		Code:     codes[message],
+		Message:  BuildMessage(message, params...),
+		Location: location,
+	}
+}
+
+// Warning turns a warning of the program into a diagnostic, so it is rendered like the errors
+func Warning(warning orth_types.CompilerMessage) *Diagnostic {
+	return &Diagnostic{
+		Severity: SeverityWarning,
+		Code:     warning.Code,
+		Message:  trimSeverity(warning.Message),
+		Location: warning.Location,
+		text:     warning.Message,
+	}
+}
+
+// trimSeverity removes the "[ERROR]" or "[WARN]" that starts a message and its surrounding blanks
+func trimSeverity(message string) string {
+	message = strings.TrimSpace(message)
+	for _, prefix := range []string{"[ERROR]", "[WARN]"} {
+		message = strings.TrimPrefix(message, prefix)
+	}
+	return strings.TrimSpace(message)
+}
+
+// AsDiagnostic returns a copy of the diagnostic within `err`, or turns a plain error into one without location
+func AsDiagnostic(err error) *Diagnostic {
+	var d *Diagnostic
+	if errors.As(err, &d) {
+		diagnostic := *d
+		diagnostic.Notes = append([]Note(nil), d.Notes...)
+		return &diagnostic
+	}
+	return &Diagnostic{
+		Severity: SeverityError,
+		Message:  trimSeverity(err.Error()),
+		text:     err.Error(),
+	}
+}
+
+// TokenError appends to `err` the macro expansions the token `v` came from, the innermost first, followed by the includes of its file
+func TokenError(v orth_types.StringEnum, err error) error {
+	d := AsDiagnostic(err)
+	if location := v.Location(); d.Location.File == location.File && d.Location.Line == location.Line && d.Location.Column == location.Column {
+		d.Location = location
+	}
+	for expansion := v.ExpandedFrom; expansion != nil; expansion = expansion.Use.ExpandedFrom {
+		d.Notes = append(d.Notes,
+			Note{Message: fmt.Sprintf("in the expansion of macro %q defined", expansion.Macro), Location: expansion.Definition.Location()},
+			Note{Message: "and used", Location: expansion.Use.Location()},
+		)
+		v = expansion.Use
+	}
+	return IncludeError(v.IncludedFrom, d)
+}
+
+// IncludeError appends to `err` the chain of `@include` directives starting at `include`
+func IncludeError(include *orth_types.StringEnum, err error) error {
+	d := AsDiagnostic(err)
+	for ; include != nil; include = include.IncludedFrom {
+		d.Notes = append(d.Notes, Note{Message: "included", Location: include.Location()})
+	}
+	return d
+}
+
+// Locate binds `err` to `location` when it was not built with one, like the errors found after parsing
+func Locate(location orth_types.Location, err error) error {
+	d := AsDiagnostic(err)
+	if d.Location.File == "" {
+		d.Location = location
+	}
+	return d
+}
+
+// Block builds the diagnostic of a block that is not closed or closed by an unexpected instruction,
+// adding a note at the instruction that opened it when there is one
+func Block(instruction, reason string, location orth_types.Location, opener string, openedAt orth_types.Location) error {
+	d := newDiagnostic(ORTH_ERR_36, instruction, reason, location.File, location.Line, location.Column)
+	d.Location = location
+	if opener != "" {
+		d.Notes = append(d.Notes, Note{Message: fmt.Sprintf("`%s` opened here", opener), Location: openedAt})
+	}
+	return d
+}
@@ -3,6 +3,7 @@ package orth_debug
 import (
 	"flag"
 	"log"
+	"strings"
 )
 
 var (
@@ -13,9 +14,40 @@ var (
 	NoLink       = flag.Bool("nl", false, "Generates the assembly whitout linking")
 	UnclearFiles = flag.Bool("uclr", false, "do not remove the generated output files")
 	I            = flag.String("I", "", "appends paths for includes separeted by ','")
+	Libs         = flag.String("l", "", "appends libraries to be linked separeted by ','")
 	Sim          = flag.Bool("sim", false, "simulate program's stack")
+	WarnEnum     = flag.Bool("wenum", false, "warns when a chain of '==' comparisons against an enum misses a member")
+	MemSize      = flag.Uint("mem", 640000, "-mem=640000 size in bytes of the mem buffer, overwritten by 'memory mem <size>'")
+	DocFormat    = flag.String("docfmt", "md", "format of the reference written by 'doc <file_path>', md or html")
+	NoAssert     = flag.Bool("noassert", false, "strips 'assert' from the program, the asserted condition is still evaluated and dropped")
+	Color        = flag.String("color", "auto", "colors the errors when stdout is a terminal: auto, always or never")
+	Diagnostics  = flag.String("diagnostics", "text", "format of the errors and warnings: text or json, one object per line")
+	DiagOut      = flag.String("diagout", "", "writes the errors and warnings to this file instead of stderr")
 )
 
+// D keeps the names of every `-D`, so the flag can be repeated as well as separeted by ','
+var D = new(definitions)
+
+func init() {
+	flag.Var(D, "D", "predefines names for the preprocessor as NAME=value or NAME separeted by ',', can be repeated")
+}
+
+// definitions is a flag that appends its values on each use instead of overwriting them
+type definitions []string
+
+func (d *definitions) String() string {
+	return strings.Join(*d, ",")
+}
+
+func (d *definitions) Set(value string) error {
+	for _, definition := range strings.Split(value, ",") {
+		if definition = strings.TrimSpace(definition); definition != "" {
+			*d = append(*d, definition)
+		}
+	}
+	return nil
+}
+
 func LogStep(message string) {
 	if !*Log {
 		return
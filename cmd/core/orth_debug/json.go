@@ -0,0 +1,90 @@
+package orth_debug
+
+import (
+	"encoding/json"
+	"io"
+
+	orth_types "orth/cmd/pkg/types"
+)
+
+// jsonDiagnostic is an error or warning written by `-diagnostics=json`, with the columns starting at 1
+// and `EndColumn` being the first one after the underlined token
+type jsonDiagnostic struct {
+	Code      string     `json:"code"`
+	Severity  string     `json:"severity"`
+	Degree    string     `json:"degree,omitempty"`
+	Message   string     `json:"message"`
+	File      string     `json:"file"`
+	Line      int        `json:"line"`
+	Column    int        `json:"column"`
+	EndColumn int        `json:"end_column"`
+	Notes     []jsonNote `json:"notes,omitempty"`
+}
+
+type jsonNote struct {
+	Message   string `json:"message"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndColumn int    `json:"end_column"`
+}
+
+// columns returns the first and the end column of `location`, both 0 when it is empty
+func (r *renderer) columns(location orth_types.Location) (int, int) {
+	if location.File == "" {
+		return 0, 0
+	}
+	source, _ := r.sourceLine(location.File, location.Line)
+	return location.Column + 1, location.Column + 1 + extent(source, location)
+}
+
+// WriteJSONDiagnostics writes the warnings followed by the errors of a program to `w`, one JSON object per line
+func WriteJSONDiagnostics(w io.Writer, errs []error, warnings []orth_types.CompilerMessage) error {
+	r := renderer{sources: make(map[string][]string)}
+	encoder := json.NewEncoder(w)
+
+	for _, warning := range warnings {
+		column, endColumn := r.columns(warning.Location)
+		err := encoder.Encode(jsonDiagnostic{
+			Code:      warning.Code,
+			Severity:  SeverityWarning.String(),
+			Degree:    warning.Type.String(),
+			Message:   trimSeverity(warning.Message),
+			File:      warning.Location.File,
+			Line:      warning.Location.Line,
+			Column:    column,
+			EndColumn: endColumn,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, e := range errs {
+		d := AsDiagnostic(e)
+		column, endColumn := r.columns(d.Location)
+		diagnostic := jsonDiagnostic{
+			Code:      d.Code,
+			Severity:  d.Severity.String(),
+			Message:   d.Message,
+			File:      d.Location.File,
+			Line:      d.Location.Line,
+			Column:    column,
+			EndColumn: endColumn,
+		}
+		for _, note := range d.Notes {
+			column, endColumn := r.columns(note.Location)
+			diagnostic.Notes = append(diagnostic.Notes, jsonNote{
+				Message:   note.Message,
+				File:      note.Location.File,
+				Line:      note.Location.Line,
+				Column:    column,
+				EndColumn: endColumn,
+			})
+		}
+		if err := encoder.Encode(diagnostic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,7 +1,6 @@
 package orth_debug
 
 import (
-	"errors"
 	"fmt"
 )
 
@@ -38,16 +37,40 @@ const (
 	ORTH_ERR_13 = "[ERROR] Incorrect number of arguments for instruction %q, required '%d' and got '%d' " + commomFileSpecificationStruct
 	ORTH_ERR_14 = "[ERROR] Incorrect number of arguments for instruction %q, required '%s' and got '%d' " + commomFileSpecificationStruct
 	ORTH_ERR_15 = "[ERROR] Could not find include file %q on paths"
+	ORTH_ERR_16 = "[ERROR] Invalid array declaration of %q: %s " + commomFileSpecificationStruct
+	ORTH_ERR_17 = "[ERROR] Memory regions can only be declared on the global scope, %q was declared " + commomFileSpecificationStruct
+	ORTH_ERR_18 = "[ERROR] Instruction %q can not operate on pointers of distinct types (%q, %q) without an explicit `cast`\n"
+	ORTH_ERR_19 = "[ERROR] Invalid enum declaration of %q: %s " + commomFileSpecificationStruct
+	ORTH_ERR_20 = "[ERROR] Could not evaluate the initializer of %q: %s " + commomFileSpecificationStruct
+	ORTH_ERR_21 = "[ERROR] Instruction %q expects a procedure of signature (%s -- %s) but %q has the signature (%s -- %s)\n"
+	ORTH_ERR_22 = "[ERROR] Invalid assembly block: %s " + commomFileSpecificationStruct
+	ORTH_ERR_23 = "[ERROR] Assembly block written for %q can not be used when compiling to %q " + commomFileSpecificationStruct
+	ORTH_ERR_24 = "[ERROR] Invalid extern declaration of %q: %s " + commomFileSpecificationStruct
+	ORTH_ERR_25 = "[ERROR] Foreign function %q can not be used when compiling to %q, only the Win64 calling convention of \"masm\" is supported " + commomFileSpecificationStruct
+	ORTH_ERR_26 = "[ERROR] Procedure %q can only return a single integer used as the exit status, but returns (%s)\n"
+	ORTH_ERR_27 = "[ERROR] Invalid %q declaration: %s " + commomFileSpecificationStruct
+	ORTH_ERR_28 = "[ERROR] Unterminated string literal " + commomFileSpecificationStruct
+	ORTH_ERR_29 = "[ERROR] Unknown directive %q " + commomFileSpecificationStruct
+	ORTH_ERR_30 = "[ERROR] Invalid directive %q: %s " + commomFileSpecificationStruct
+	ORTH_ERR_31 = "[ERROR] Recursive expansion of macro %q (%s) " + commomFileSpecificationStruct
+	ORTH_ERR_32 = "[ERROR] Invalid call of macro %q: %s " + commomFileSpecificationStruct
+	ORTH_ERR_33 = "[ERROR] Include cycle (%s) " + commomFileSpecificationStruct
+	ORTH_ERR_34 = "[ERROR] Invalid reference %q: %s " + commomFileSpecificationStruct
+	ORTH_ERR_35 = "[ERROR] Unterminated block comment " + commomFileSpecificationStruct
+	ORTH_ERR_36 = "[ERROR] Unbalanced block %q: %s " + commomFileSpecificationStruct
+	ORTH_ERR_37 = "[ERROR] Instruction %q is a raw Linux syscall, which is not supported: the only backend, masm, targets Windows " + commomFileSpecificationStruct
 )
 
 const (
 	ORTH_WARN_01 = "[WARN] Performin operation %q on values with distinct types (%q, %q)\n"
+	ORTH_WARN_02 = "[WARN] Comparisons against enum %q in %q are missing the members: %s"
 )
 
 func BuildMessage(message string, params ...interface{}) string {
 	return fmt.Sprintf(message, params...)
 }
 
+// BuildErrorMessage builds the diagnostic of one of the messages above
 func BuildErrorMessage(message string, params ...interface{}) error {
-	return errors.New(BuildMessage(message, params...))
+	return newDiagnostic(message, params...)
 }
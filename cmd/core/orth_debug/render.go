@@ -0,0 +1,205 @@
+package orth_debug
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	orth_types "orth/cmd/pkg/types"
+)
+
+const (
+	ansiReset = "\033[0m"
+	ansiBold  = "\033[1m"
+	ansiRed   = "\033[1;31m"
+	ansiYelow = "\033[1;33m"
+	ansiBlue  = "\033[1;34m"
+)
+
+// ColorEnabled checks if the diagnostics written to `file` should be colored, which by default
+// only happens when stdout is a terminal. `-color=always` and `-color=never` force it
+func ColorEnabled(file *os.File) bool {
+	switch *Color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	for _, f := range []*os.File{os.Stdout, file} {
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// renderer writes diagnostics in the style of rustc, showing the lines of source code they point to
+type renderer struct {
+	colored bool
+	// sources are the lines of the files already read, nil for the ones that could not be read
+	sources map[string][]string
+}
+
+// RenderDiagnostics renders the warnings followed by every error in `errs`, each one followed by a blank line
+func RenderDiagnostics(errs []error, warnings []orth_types.CompilerMessage, colored bool) string {
+	r := renderer{colored: colored, sources: make(map[string][]string)}
+	var text strings.Builder
+	for _, warning := range warnings {
+		text.WriteString(r.render(Warning(warning)))
+		text.WriteString("\n")
+	}
+	for _, err := range errs {
+		text.WriteString(r.render(AsDiagnostic(err)))
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+func (r *renderer) paint(color, text string) string {
+	if !r.colored {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// sourceLine returns the line `line` of `file`, false when the file or the line do not exist
+func (r *renderer) sourceLine(file string, line int) (string, bool) {
+	lines, read := r.sources[file]
+	if !read {
+		content, err := os.ReadFile(file)
+		if err == nil {
+			lines = strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+		}
+		r.sources[file] = lines
+	}
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+	return lines[line-1], true
+}
+
+// underline marks the columns of `location` in `source` with `mark`, keeping the tabs
+// before them so the marks are aligned with the line above. Columns are byte offsets, as given
+// by the scanner, and a mark is written for each character they cover
+func underline(source string, location orth_types.Location, mark string) string {
+	column := min(location.Column, len(source))
+	length := extent(source, location)
+
+	var padding strings.Builder
+	for _, char := range source[:column] {
+		if char == '\t' {
+			padding.WriteRune('\t')
+		} else {
+			padding.WriteRune(' ')
+		}
+	}
+	marks := max(utf8.RuneCountInString(source[column:min(column+length, len(source))]), 1)
+	return padding.String() + strings.Repeat(mark, marks)
+}
+
+// extent is the amount of bytes covered by `location` on its line of code `source`, which for locations
+// that are not bound to a token goes up to the end of the word they start
+func extent(source string, location orth_types.Location) int {
+	column := min(location.Column, len(source))
+
+	length := location.Length
+	if length != 0 {
+		return length
+	}
+	for column+length < len(source) {
+		char, size := utf8.DecodeRuneInString(source[column+length:])
+		if unicode.IsSpace(char) {
+			break
+		}
+		length += size
+	}
+	return max(length, 1)
+}
+
+// label is a place of the source code pointed by a diagnostic, `primary` for where the problem is
+type label struct {
+	location orth_types.Location
+	message  string
+	primary  bool
+}
+
+func (r *renderer) render(d *Diagnostic) string {
+	var text strings.Builder
+
+	severityColor := ansiRed
+	if d.Severity == SeverityWarning {
+		severityColor = ansiYelow
+	}
+	header := d.Severity.String()
+	if d.Code != "" {
+		header = fmt.Sprintf("%s[%s]", header, d.Code)
+	}
+	text.WriteString(r.paint(severityColor, header))
+	text.WriteString(r.paint(ansiBold, ": "+d.Message))
+	text.WriteString("\n")
+
+	labels := make([]label, 0, len(d.Notes)+1)
+	if d.Location.File != "" {
+		labels = append(labels, label{location: d.Location, primary: true})
+	}
+	loose := make([]Note, 0)
+	for _, note := range d.Notes {
+		if note.Location.File == "" {
+			loose = append(loose, note)
+			continue
+		}
+		// notes read as "... in <file> at line" on plain text, while a label stands below the place itself
+		message := note.Message
+		if !strings.HasSuffix(message, "here") {
+			message += " here"
+		}
+		labels = append(labels, label{location: note.Location, message: message})
+	}
+
+	gutter := 0
+	for _, l := range labels {
+		gutter = max(gutter, len(strconv.Itoa(l.location.Line)))
+	}
+	blank := strings.Repeat(" ", gutter)
+
+	file := ""
+	for i, l := range labels {
+		if l.location.File != file {
+			arrow := "-->"
+			if i != 0 {
+				arrow = ":::"
+			}
+			text.WriteString(fmt.Sprintf("%s%s %s:%d:%d\n", blank, r.paint(ansiBlue, arrow), l.location.File, l.location.Line, l.location.Column+1))
+			file = l.location.File
+		}
+
+		source, ok := r.sourceLine(l.location.File, l.location.Line)
+		if !ok {
+			// the location still has to be told when there is no source to show, like the command line
+			if !l.primary {
+				text.WriteString(fmt.Sprintf("%s %s %s at %s:%d:%d\n", blank, r.paint(ansiBlue, "="), l.message, l.location.File, l.location.Line, l.location.Column+1))
+			}
+			continue
+		}
+		mark, color := "-", ansiBlue
+		if l.primary {
+			mark, color = "^", severityColor
+		}
+		text.WriteString(fmt.Sprintf("%s %s\n", blank, r.paint(ansiBlue, "|")))
+		text.WriteString(fmt.Sprintf("%s %s %s\n", r.paint(ansiBlue, fmt.Sprintf("%*d", gutter, l.location.Line)), r.paint(ansiBlue, "|"), source))
+		marks := r.paint(color, underline(source, l.location, mark))
+		if l.message != "" {
+			marks += " " + r.paint(color, l.message)
+		}
+		text.WriteString(fmt.Sprintf("%s %s %s\n", blank, r.paint(ansiBlue, "|"), marks))
+	}
+
+	for _, note := range loose {
+		text.WriteString(fmt.Sprintf("%s %s %s\n", blank, r.paint(ansiBlue, "="), r.paint(ansiBold, "note: ")+note.Message))
+	}
+	return text.String()
+}
@@ -6,7 +6,6 @@ import (
 	"orth/cmd/core/orth_debug"
 	"orth/cmd/pkg/helpers"
 	orth_types "orth/cmd/pkg/types"
-	"os"
 	"strconv"
 	"strings"
 )
@@ -329,8 +328,7 @@ func MultplyIntegers(superType string, n1, n2 orth_types.Operand) orth_types.Ope
 
 func BitwiseAnd(superType string, n1, n2 orth_types.Operand) orth_types.Operand {
 	if !helpers.IsInt(n1) || !helpers.IsInt(n2) {
-		fmt.Fprintln(os.Stderr, "cannot perform 'logical and' on values that are not integers")
-		os.Exit(1)
+		panic(errors.New("cannot perform 'logical and' on values that are not integers"))
 	}
 
 	left := helpers.ToInt(n1)
@@ -344,8 +342,7 @@ func BitwiseAnd(superType string, n1, n2 orth_types.Operand) orth_types.Operand
 
 func BitwiseOr(superType string, n1, n2 orth_types.Operand) orth_types.Operand {
 	if _, ok := orth_types.GlobalTypes[orth_types.INTS][superType]; !ok {
-		fmt.Fprintln(os.Stderr, "cannot perform 'logical or' on values that are not integers")
-		os.Exit(1)
+		panic(errors.New("cannot perform 'logical or' on values that are not integers"))
 	}
 
 	left := helpers.ToInt(n1)
@@ -386,8 +383,7 @@ func DivideIntegers(superType string, n1, n2 orth_types.Operand) orth_types.Oper
 func LeftShiftFloat(superType string, n1, n2 orth_types.Operand) orth_types.Operand {
 	shiftAmount, err := strconv.Atoi(n1.Operand)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		panic(err)
 	}
 
 	bitSize := 32
@@ -397,8 +393,7 @@ func LeftShiftFloat(superType string, n1, n2 orth_types.Operand) orth_types.Oper
 	}
 	floatValue, err := strconv.ParseFloat(n2.Operand, bitSize)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		panic(err)
 	}
 
 	decimalDigits := (floatValue - float64(int(floatValue)))
@@ -420,8 +415,7 @@ func LeftShiftInt(superType string, n1, n2 orth_types.Operand) orth_types.Operan
 	shiftAmount, _ := strconv.Atoi(n1.Operand)
 	intValue, err := strconv.Atoi(n2.Operand)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		panic(err)
 	}
 	intValue = intValue << shiftAmount
 
@@ -434,8 +428,7 @@ func LeftShiftInt(superType string, n1, n2 orth_types.Operand) orth_types.Operan
 func RightShiftFloat(superType string, n1, n2 orth_types.Operand) orth_types.Operand {
 	shiftAmount, err := strconv.Atoi(n1.Operand)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		panic(err)
 	}
 
 	bitSize := 32
@@ -445,8 +438,7 @@ func RightShiftFloat(superType string, n1, n2 orth_types.Operand) orth_types.Ope
 	}
 	floatValue, err := strconv.ParseFloat(n2.Operand, bitSize)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		panic(err)
 	}
 
 	decimalDigits := (floatValue - float64(int(floatValue)))
@@ -468,8 +460,7 @@ func RightShiftInt(superType string, n1, n2 orth_types.Operand) orth_types.Opera
 	shiftAmount, _ := strconv.Atoi(n1.Operand)
 	intValue, err := strconv.Atoi(n2.Operand)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		panic(err)
 	}
 	intValue = intValue >> shiftAmount
 
@@ -488,8 +479,7 @@ func SumIntegers(superType string, n1, n2 orth_types.Operand) orth_types.Operand
 	case orth_types.StdAddress:
 		sum, err = SumAddress(n1, n2)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			panic(err)
 		}
 	case orth_types.StdI64:
 		sum = SumI64(n1, n2)
@@ -502,8 +492,7 @@ func SumIntegers(superType string, n1, n2 orth_types.Operand) orth_types.Operand
 	case orth_types.StdINT:
 		sum = SumI(n1, n2)
 	default:
-		fmt.Fprintln(os.Stderr, "not an integer")
-		os.Exit(1)
+		panic(errors.New("not an integer"))
 	}
 
 	return orth_types.Operand{
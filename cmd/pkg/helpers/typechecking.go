@@ -14,7 +14,33 @@ func IsNumeric(op orth_types.Operand) bool {
 
 func ToAddress(op orth_types.Operand) (int, bool) {
 	address, err := strconv.Atoi(op.Operand)
-	return address, IsInt(op) && err == nil
+	return address, IsAddress(op) && err == nil
+}
+
+// IsAddress checks if an operand can be used as an address, either an integer or a typed pointer
+func IsAddress(op orth_types.Operand) bool {
+	return IsInt(op) || orth_types.IsPointerType(op.SymbolName)
+}
+
+// PointerArithmeticType returns the resulting type of an arithmetic between `left` and `right`
+// when at least one of them is a pointer. Pointers of distinct pointee types can not be mixed.
+func PointerArithmeticType(left, right orth_types.Operand) (string, bool, error) {
+	leftIsPointer := orth_types.IsPointerType(left.SymbolName)
+	rightIsPointer := orth_types.IsPointerType(right.SymbolName)
+
+	switch {
+	case leftIsPointer && rightIsPointer:
+		if left.SymbolName != right.SymbolName {
+			return "", true, fmt.Errorf("mismatch pointer types %q and %q", left.SymbolName, right.SymbolName)
+		}
+		return left.SymbolName, true, nil
+	case leftIsPointer:
+		return left.SymbolName, true, nil
+	case rightIsPointer:
+		return right.SymbolName, true, nil
+	default:
+		return "", false, nil
+	}
 }
 
 func IsInt(op orth_types.Operand) bool {
@@ -0,0 +1,168 @@
+package simulation
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Input is read by the simulated program as its stdin
+var Input io.Reader = os.Stdin
+
+// file modes accepted by `fopen`
+const (
+	fileModeRead = iota
+	fileModeWrite
+	fileModeAppend
+)
+
+// fileTable maps the handles seen by a simulated program to the host files,
+// 0, 1 and 2 being stdin, stdout and stderr like on the compiled program
+type fileTable struct {
+	handles map[int]*os.File
+	input   *bufio.Reader
+	next    int
+}
+
+func newFileTable() *fileTable {
+	return &fileTable{
+		handles: map[int]*os.File{
+			0: os.Stdin,
+			1: os.Stdout,
+			2: os.Stderr,
+		},
+		input: bufio.NewReader(Input),
+		next:  3,
+	}
+}
+
+// open returns the handle of the opened file or -1 when it can not be opened
+func (t *fileTable) open(path string, mode int) int {
+	var flags int
+	switch mode {
+	case fileModeRead:
+		flags = os.O_RDONLY
+	case fileModeWrite:
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	case fileModeAppend:
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	default:
+		return -1
+	}
+
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return -1
+	}
+	handle := t.next
+	t.handles[handle] = file
+	t.next++
+	return handle
+}
+
+// read returns the amount of bytes read into `buffer` or -1 on failure, 0 meaning the end of the file
+func (t *fileTable) read(handle int, buffer []byte) int {
+	var reader io.Reader = t.input
+	if handle != 0 {
+		file, ok := t.handles[handle]
+		if !ok {
+			return -1
+		}
+		reader = file
+	}
+	n, err := reader.Read(buffer)
+	if errors.Is(err, io.EOF) {
+		return 0
+	}
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// write returns the amount of bytes written or -1 on failure
+func (t *fileTable) write(handle int, buffer []byte) int {
+	file, ok := t.handles[handle]
+	if !ok {
+		return -1
+	}
+	n, err := file.Write(buffer)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func (t *fileTable) close(handle int) {
+	if file, ok := t.handles[handle]; ok {
+		file.Close()
+		delete(t.handles, handle)
+	}
+}
+
+// size returns the size in bytes of the file or -1 when it is not a regular file
+func (t *fileTable) size(handle int) int {
+	file, ok := t.handles[handle]
+	if !ok {
+		return -1
+	}
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return -1
+	}
+	return int(info.Size())
+}
+
+// getChar returns the next byte of the input or -1 at its end
+func (t *fileTable) getChar() int {
+	c, err := t.input.ReadByte()
+	if err != nil {
+		return -1
+	}
+	return int(c)
+}
+
+// readLine returns the next line of the input without its line break,
+// `ok` is false only when the input had already ended
+func (t *fileTable) readLine() (line []byte, ok bool) {
+	content, err := t.input.ReadString('\n')
+	if err != nil && content == "" {
+		return nil, false
+	}
+	return []byte(strings.TrimRight(content, "\r\n")), true
+}
+
+// readInt parses the signed decimal at the start of the next non blank line of the input,
+// the rest of the line is discarded
+func (t *fileTable) readInt() int {
+	for {
+		line, ok := t.readLine()
+		if !ok {
+			return 0
+		}
+		trimmed := strings.TrimLeft(string(line), " \t")
+		if trimmed == "" {
+			continue
+		}
+		end := 0
+		if trimmed[0] == '-' {
+			end++
+		}
+		for end < len(trimmed) && trimmed[end] >= '0' && trimmed[end] <= '9' {
+			end++
+		}
+		value, _ := strconv.Atoi(trimmed[:end])
+		return value
+	}
+}
+
+// unlink returns 0 when the file was removed or -1 otherwise
+func unlink(path string) int {
+	if err := os.Remove(path); err != nil {
+		return -1
+	}
+	return 0
+}
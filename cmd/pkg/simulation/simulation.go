@@ -1,13 +1,23 @@
 package simulation
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	embedded_helpers "orth/cmd/core/embedded/helpers"
+	"orth/cmd/core/orth_debug"
 	"orth/cmd/pkg/helpers"
 	"orth/cmd/pkg/helpers/functions"
 	orth_types "orth/cmd/pkg/types"
 	"os"
+	"runtime"
+	"strconv"
+	"strings"
 )
 
+// simulationExit is raised by `exit` to stop the simulation with its status
+type simulationExit int
+
 type doubleOperandsOperationtionGroup struct {
 	Integer func(superType string, n1, n2 orth_types.Operand) orth_types.Operand
 	Float   func(superType string, n1, n2 orth_types.Operand) orth_types.Operand
@@ -20,6 +30,9 @@ type stack struct {
 
 func (s *stack) push(itens ...orth_types.Operation) {
 	for _, item := range itens {
+		if s.ptr+1 >= len(s.items) {
+			panic("stack overflow")
+		}
 		s.ptr++
 		(s.items)[s.ptr] = item
 	}
@@ -36,9 +49,13 @@ func (s *stack) rmv(numItensToRemove int) {
 	}
 }
 
+// peek returns the `numItens` values on top of the stack, the deepest one first
 func (s *stack) peek(numItens int) []orth_types.Operation {
+	if s.ptr+1 < numItens {
+		panic("stack underflow")
+	}
 	preview := make([]orth_types.Operation, numItens)
-	copy(preview, (s.items)[:numItens])
+	copy(preview, (s.items)[s.ptr+1-numItens:s.ptr+1])
 	return preview
 }
 
@@ -48,12 +65,124 @@ func addToMem(stack *[]orth_types.Operation, offset int, itens ...orth_types.Ope
 	}
 }
 
+// formatNumber formats `value` in `base` the same way the compiled program prints it,
+// left padding it with spaces up to `width`
+func formatNumber(value orth_types.Operation, operation orth_types.Operation, base int, signed bool, width int) string {
+	if !helpers.IsInt(value.Operator) {
+		panic(fmt.Errorf("cannot have type %q used for %q instruction\n", value.Operator.SymbolName, orth_types.InstructionToStr(operation.Instruction)))
+	}
+	n := helpers.ToInt(value.Operator)
+	digits := strconv.FormatUint(uint64(n), base)
+	if signed {
+		digits = strconv.FormatInt(int64(n), base)
+	}
+	return fmt.Sprintf("%*s", width, digits)
+}
+
+// exitStatusInRange checks if the host can return `status` as the exit status of a process
+func exitStatusInRange(status int) bool {
+	if runtime.GOOS == "windows" {
+		return status >= math.MinInt32 && status <= math.MaxUint32
+	}
+	return status >= 0 && status <= 255
+}
+
+// pushInteger pushes the i64 result of `operation` into the stack
+func pushInteger(stack *stack, operation orth_types.Operation, value int) {
+	stack.push(orth_types.Operation{
+		Instruction: orth_types.InstructionPush,
+		Context:     operation.Context,
+		Operator: orth_types.Operand{
+			SymbolName: orth_types.StdI64,
+			Operand:    strconv.Itoa(value),
+		},
+	})
+}
+
+// pushDeclared pushes a zero value for each of the `params` declared by `main`, which nothing calls
+func pushDeclared(stack *stack, operation orth_types.Operation, params []orth_types.Operation) {
+	for _, param := range params {
+		stack.push(orth_types.Operation{
+			Instruction: orth_types.InstructionPush,
+			Context:     operation.Context,
+			Operator: orth_types.Operand{
+				SymbolName: param.Operator.Operand,
+				Operand:    "0",
+			},
+		})
+	}
+}
+
+// loadBytes reads the buffer pointed by `pointer`, either a string or an address of the virtual mem.
+// A negative `size` reads until the first zero byte
+func loadBytes(virtualMem []orth_types.Operation, pointer orth_types.Operation, size int) ([]byte, error) {
+	if helpers.IsString(pointer.Operator.SymbolName) {
+		// strings keep the escape sequences written in the source, which the compiler unquotes into bytes
+		unquoted, err := strconv.Unquote(`"` + pointer.Operator.Operand + `"`)
+		if err != nil {
+			return nil, err
+		}
+		content := []byte(unquoted)
+		if size >= 0 && size < len(content) {
+			content = content[:size]
+		}
+		return content, nil
+	}
+
+	address, ok := helpers.ToAddress(pointer.Operator)
+	if !ok {
+		return nil, fmt.Errorf("cannot have type %q used as a buffer", pointer.Operator.SymbolName)
+	}
+	buffer := make([]byte, 0)
+	for i := address; i < len(virtualMem) && (size < 0 || i < address+size); i++ {
+		value, _ := strconv.Atoi(virtualMem[i].Operator.Operand)
+		if size < 0 && value == 0 {
+			break
+		}
+		buffer = append(buffer, byte(value))
+	}
+	return buffer, nil
+}
+
+// storeBytes writes `buffer` into the virtual mem starting at the address held by `pointer`
+func storeBytes(virtualMem []orth_types.Operation, pointer orth_types.Operation, buffer []byte) error {
+	address, ok := helpers.ToAddress(pointer.Operator)
+	if !ok || address < 0 || address+len(buffer) > len(virtualMem) {
+		return fmt.Errorf("cannot store %d bytes read at %q", len(buffer), pointer.Operator.Operand)
+	}
+	for i, b := range buffer {
+		virtualMem[address+i] = orth_types.Operation{
+			Instruction: orth_types.InstructionPush,
+			Operator: orth_types.Operand{
+				SymbolName: orth_types.StdI8,
+				Operand:    strconv.Itoa(int(b)),
+			},
+		}
+	}
+	return nil
+}
+
+// topFirst gives the top of the stack as the first operand of `operation`, the order
+// in which the compiled `<` and `>` compare their values
+func topFirst(operation func(superType string, n1, n2 orth_types.Operand) orth_types.Operand) func(superType string, n1, n2 orth_types.Operand) orth_types.Operand {
+	return func(superType string, n1, n2 orth_types.Operand) orth_types.Operand {
+		return operation(superType, n2, n1)
+	}
+}
+
 func operateDoubleValueStack(stack *stack, operationGroup doubleOperandsOperationtionGroup) {
 	preview := stack.peek(2)
+	superType := preview[0].Operator.SymbolName
 
-	if err := helpers.OperatingOnEqualTypes(preview...); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	// pointers can be offseted by any integer, but never mixed with pointers of other types
+	pointerType, isPointerArithmetic, err := helpers.PointerArithmeticType(preview[0].Operator, preview[1].Operator)
+	if err != nil {
+		panic(err)
+	}
+	if isPointerArithmetic {
+		superType = orth_types.StdI64
+	} else if err := helpers.OperatingOnEqualTypes(preview...); err != nil {
+		panic(err)
 	}
 
 	var operation func(superType string, n1, n2 orth_types.Operand) orth_types.Operand
@@ -63,7 +192,10 @@ func operateDoubleValueStack(stack *stack, operationGroup doubleOperandsOperatio
 		operation = operationGroup.Integer
 	}
 
-	result := operation(preview[0].Operator.SymbolName, preview[0].Operator, preview[1].Operator)
+	result := operation(superType, preview[0].Operator, preview[1].Operator)
+	if isPointerArithmetic && result.SymbolName == superType {
+		result.SymbolName = pointerType
+	}
 	stack.rmv(2)
 	stack.push(orth_types.Operation{
 		Instruction: orth_types.InstructionPush,
@@ -71,16 +203,208 @@ func operateDoubleValueStack(stack *stack, operationGroup doubleOperandsOperatio
 	})
 }
 
+// checkSignatureParams checks the values on the stack against the in params declared by
+// `call*` and `asm`, returning how many they are
+func checkSignatureParams(stack *stack, operation orth_types.Operation) int {
+	paramsAmount := 0
+	for k := range operation.Links {
+		if strings.HasPrefix(k, "proc_param_") {
+			paramsAmount++
+		}
+	}
+	preview := stack.peek(paramsAmount)
+	for i, stackItem := range preview {
+		paramType := operation.Links[fmt.Sprintf("proc_param_%d", i)].Operator.Operand
+		if paramType != stackItem.Operator.SymbolName {
+			panic(fmt.Errorf("Proc param required type %q but got %q", paramType, stackItem.Operator.SymbolName))
+		}
+	}
+	return paramsAmount
+}
+
+// removeSignatureParams checks the in params of foreign code, which can not be simulated, removing them afterwards
+func removeSignatureParams(stack *stack, operation orth_types.Operation) {
+	stack.rmv(checkSignatureParams(stack, operation))
+}
+
+// truthy checks a condition the way the compiled program does, where anything but zero is true
+func truthy(condition orth_types.Operand) bool {
+	if helpers.IsBool(condition) {
+		return condition.Operand == orth_types.StdTrue
+	}
+	if !helpers.IsAddress(condition) {
+		panic(fmt.Errorf("cannot have type %q used as a condition", condition.SymbolName))
+	}
+	value, _ := strconv.Atoi(condition.Operand)
+	return value != 0
+}
+
+// checkArrayIndex validates an index used by `idx@`/`idx!` against the length of the array
+func checkArrayIndex(index orth_types.Operand, array orth_types.Operation, instruction orth_types.Instruction) int {
+	if !helpers.IsInt(index) {
+		panic(fmt.Errorf(orth_debug.InvalidTypeForIndex+"\n", orth_types.INTS))
+	}
+	i := helpers.ToInt(index)
+	if i < 0 || i >= array.ArrayLength() {
+		panic(fmt.Errorf(orth_debug.IndexOutOfBounds+" for instruction %q\n", i, 0, array.ArrayLength()-1, orth_types.InstructionToStr(instruction)))
+	}
+	return i
+}
+
+// elementWidth is the amount of bytes a value of the `operand` type takes in the virtual mem,
+// anything that is neither a number nor a typed pointer being held as a qword
+func elementWidth(operand orth_types.Operand) int {
+	_, isInt := orth_types.GlobalTypes[orth_types.INTS][operand.SymbolName]
+	_, isFloat := orth_types.GlobalTypes[orth_types.FLOATS][operand.SymbolName]
+	if operand.SymbolName == orth_types.StdAddress || (!isInt && !isFloat && !orth_types.IsPointerType(operand.SymbolName)) {
+		return 8
+	}
+	_, width := embedded_helpers.VarTypeToPtrSize(operand)
+	return width
+}
+
+// pointeeType is the type `operation` was linked to by the type checking of pointers,
+// or `untyped` when the pointer it uses does not have a known pointee
+func pointeeType(operation orth_types.Operation, untyped string) string {
+	if pointee, ok := operation.Links["pointee"]; ok {
+		return pointee.Operator.SymbolName
+	}
+	return untyped
+}
+
+// loadPointee reads the value held by the virtual mem at `pointer` as a value of the `pointee` type,
+// keeping only its width, sign extended or not, like the compiled program does
+func loadPointee(virtualMem []orth_types.Operation, pointer orth_types.Operation, operation orth_types.Operation, pointee string, signed bool) orth_types.Operation {
+	address, ok := helpers.ToAddress(pointer.Operator)
+	if !ok {
+		panic(fmt.Errorf("cannot non addressable value for instruction %q\n", orth_types.InstructionToStr(operation.Instruction)))
+	}
+	if address < 0 || address >= len(virtualMem) {
+		panic(fmt.Errorf("%q address out of the virtual mem: max allowed %d | actual %d", orth_types.InstructionToStr(operation.Instruction), len(virtualMem)-1, address))
+	}
+
+	value := virtualMem[address]
+	value.Instruction = orth_types.InstructionPush
+	value.Context = operation.Context
+	if value.Operator.Operand == "" {
+		value.Operator.Operand = "0"
+	}
+	value.Operator.SymbolName = pointee
+	if helpers.IsInt(value.Operator) {
+		loaded := zeroExtend(helpers.ToInt(value.Operator), elementWidth(value.Operator))
+		if signed {
+			loaded = truncate(loaded, elementWidth(value.Operator))
+		}
+		value.Operator.Operand = strconv.Itoa(loaded)
+	}
+	return value
+}
+
+// variableKey identifies a var/const by the context it was declared in and its name
+type variableKey struct {
+	context *orth_types.Context
+	name    string
+}
+
+// layoutVariables appends every var/const declared in `program` to the virtual mem, after the mem itself, with
+// each element `width` slots apart like in the compiled program. Returns where each of them starts
+func layoutVariables(program *orth_types.Program, virtualMem *[]orth_types.Operation) map[variableKey]int {
+	variables := make(map[variableKey]int)
+	for _, operation := range program.Operations {
+		if operation.Instruction != orth_types.InstructionVar && operation.Instruction != orth_types.InstructionConst {
+			continue
+		}
+		elementValue := operation.Links["variable_value"].Operator
+		width := elementWidth(elementValue)
+
+		// a variable that is not an array is laid out as a single element
+		values := []string{elementValue.Operand}
+		length := 1
+		if operation.IsArray() {
+			values = nil
+			if elementValue.Operand != "" {
+				values = strings.Split(elementValue.Operand, ",")
+			}
+			length = operation.ArrayLength()
+		}
+		variables[variableKey{operation.Context, operation.Operator.Operand}] = len(*virtualMem)
+		for i := 0; i < length; i++ {
+			element := "0"
+			if i < len(values) {
+				element = values[i]
+			}
+			*virtualMem = append(*virtualMem, orth_types.Operation{
+				Instruction: orth_types.InstructionPush,
+				Context:     operation.Context,
+				Operator: orth_types.Operand{
+					SymbolName: elementValue.SymbolName,
+					Operand:    element,
+				},
+			})
+			*virtualMem = append(*virtualMem, make([]orth_types.Operation, width-1)...)
+		}
+	}
+	return variables
+}
+
+// arrayElement returns the slot of the virtual mem holding the element `index` of `array`, and the width of its elements
+func arrayElement(variables map[variableKey]int, array orth_types.Operation, index int) (int, int) {
+	width := elementWidth(array.Links["variable_value"].Operator)
+	return variables[variableKey{array.Context, array.Operator.Operand}] + index*width, width
+}
+
+// truncate keeps the `width` lower bytes of `value` sign extended, as storing it into memory and loading it back does
+func truncate(value, width int) int {
+	shift := 64 - 8*width
+	return int(int64(value) << shift >> shift)
+}
+
+// zeroExtend keeps the `width` lower bytes of `value`, as loading it with `,` does
+func zeroExtend(value, width int) int {
+	shift := 64 - 8*width
+	return int(uint64(value) << shift >> shift)
+}
+
 // SimulateStack is an optional step that preceeds compilation, checking for errors, underflows, overflows
-// and other things that a programmer like me would do without even thinking
-func SimulateStack(program *orth_types.Program) {
-	virtualMem := make([]orth_types.Operation, helpers.MEM_MAX_CAP)
+// and other things that a programmer like me would do without even thinking.
+// The program runs from `main`, following the jumps of blocks and calls like the compiled program does.
+// It returns the exit status given by the program, or the runtime error that stopped the simulation
+func SimulateStack(program *orth_types.Program) (status int, err error) {
+	defer func() {
+		switch r := recover().(type) {
+		case nil:
+		case simulationExit:
+			status = int(r)
+		default:
+			// besides errors, the stack and the helpers of operations panic with plain strings
+			err = errors.New(strings.TrimSuffix(fmt.Sprint(r), "\n"))
+		}
+	}()
+
+	memCapacity := program.MemCapacity()
+	virtualMem := make([]orth_types.Operation, memCapacity)
+	variables := layoutVariables(program, &virtualMem)
 	stack := stack{
 		ptr:   -1,
 		items: make([]orth_types.Operation, 1024),
 	}
+	files := newFileTable()
 
+	procs := make(map[string]int)
 	for ip, operation := range program.Operations {
+		if operation.Instruction == orth_types.InstructionProc {
+			procs[operation.Operator.Operand] = ip
+		}
+	}
+	mainAddress, hasMain := procs["main"]
+	if !hasMain {
+		return 0, nil
+	}
+	// where each call goes back to once its proc ends, `main` being the only proc not called
+	returns := make([]int, 0)
+
+	for ip := mainAddress + 1; ip < len(program.Operations); ip++ {
+		operation := program.Operations[ip]
 		switch operation.Instruction {
 		case orth_types.InstructionPush:
 			fallthrough
@@ -113,18 +437,18 @@ func SimulateStack(program *orth_types.Program) {
 			})
 		case orth_types.InstructionLt:
 			operateDoubleValueStack(&stack, doubleOperandsOperationtionGroup{
-				Integer: functions.LowerThanInts,
-				Float:   functions.LowerThanFloats,
+				Integer: topFirst(functions.LowerThanInts),
+				Float:   topFirst(functions.LowerThanFloats),
 			})
 		case orth_types.InstructionGt:
 			operateDoubleValueStack(&stack, doubleOperandsOperationtionGroup{
-				Integer: functions.GreaterThanInts,
-				Float:   functions.GreaterThanFloats,
+				Integer: topFirst(functions.GreaterThanInts),
+				Float:   topFirst(functions.GreaterThanFloats),
 			})
 		case orth_types.InstructionNotEqual:
 			operateDoubleValueStack(&stack, doubleOperandsOperationtionGroup{
-				Integer: functions.EqualInts,
-				Float:   functions.EqualFloats,
+				Integer: functions.DiffInts,
+				Float:   functions.DiffFloats,
 			})
 		case orth_types.InstructionMod:
 			operateDoubleValueStack(&stack, doubleOperandsOperationtionGroup{
@@ -132,15 +456,38 @@ func SimulateStack(program *orth_types.Program) {
 				Float:   functions.ModFloats,
 			})
 		case orth_types.InstructionDup:
-			preview := stack.peek(2)
-			stack.push(preview[1], preview[0], preview[1])
+			preview := stack.peek(1)
+			stack.push(preview[0])
 		case orth_types.InstructionTwoDup:
 			preview := stack.peek(2)
-			stack.push(preview[1], preview[0], preview[1], preview[1])
+			stack.push(preview...)
 		case orth_types.FunctionPutU64:
-			fallthrough
+			files.write(1, []byte(formatNumber(stack.peek(1)[0], operation, 10, false, 0)))
+			stack.rmv(1)
+		case orth_types.FunctionPutI64:
+			files.write(1, []byte(formatNumber(stack.peek(1)[0], operation, 10, true, 0)))
+			stack.rmv(1)
+		case orth_types.FunctionPutHex:
+			files.write(1, []byte(formatNumber(stack.peek(1)[0], operation, 16, false, 0)))
+			stack.rmv(1)
+		case orth_types.FunctionPutBin:
+			files.write(1, []byte(formatNumber(stack.peek(1)[0], operation, 2, false, 0)))
+			stack.rmv(1)
+		case orth_types.FunctionPutU64Pad:
+			preview := stack.peek(2)
+			files.write(1, []byte(formatNumber(preview[1], operation, 10, false, helpers.ToInt(preview[0].Operator))))
+			stack.rmv(2)
+		case orth_types.FunctionPutBool:
+			preview := stack.peek(1)
+			files.write(1, []byte(strconv.FormatBool(preview[0].Operator.Operand != orth_types.StdFalse)))
+			stack.rmv(1)
 		case orth_types.FunctionPutString:
-			fallthrough
+			content, err := loadBytes(virtualMem, stack.peek(1)[0], -1)
+			if err != nil {
+				panic(err)
+			}
+			files.write(1, content)
+			stack.rmv(1)
 		case orth_types.InstructionDrop:
 			stack.rmv(1)
 		case orth_types.InstructionSwap:
@@ -152,88 +499,157 @@ func SimulateStack(program *orth_types.Program) {
 		case orth_types.InstructionStore:
 			preview := stack.peek(2)
 			for _, item := range preview {
-				if !helpers.IsInt(item.Operator) {
-					fmt.Fprintf(os.Stderr, "cannot have type %q used for %q instruction", item.Operator.SymbolName, orth_types.InstructionToStr(orth_types.InstructionStore))
-					os.Exit(1)
+				if !helpers.IsAddress(item.Operator) {
+					panic(fmt.Errorf("cannot have type %q used for %q instruction", item.Operator.SymbolName, orth_types.InstructionToStr(orth_types.InstructionStore)))
 				}
 			}
 			// add to mem
-			offset := helpers.ToInt(preview[0].Operator)
-			memPtr := helpers.ToInt(preview[1].Operator)
+			offset, _ := helpers.ToAddress(preview[0].Operator)
+			memPtr, _ := helpers.ToAddress(preview[1].Operator)
 
 			if offset+memPtr < 0 {
-				fmt.Fprintf(os.Stderr, "cannot have a negative offset access for %q. Expected x >= 0 got '%d'", orth_types.InstructionToStr(orth_types.InstructionStore), offset+memPtr)
-				os.Exit(1)
+				panic(fmt.Errorf("cannot have a negative offset access for %q. Expected x >= 0 got '%d'", orth_types.InstructionToStr(orth_types.InstructionStore), offset+memPtr))
 			}
 
-			if offset > int(helpers.MEM_MAX_CAP) {
-				fmt.Fprintf(os.Stderr, "%q offset larger than mem_max_cap: max allowed %d | actual %d", orth_types.InstructionToStr(orth_types.InstructionStore), helpers.MEM_MAX_CAP, offset)
-				os.Exit(1)
+			if offset >= len(virtualMem) {
+				panic(fmt.Errorf("%q offset larger than mem_max_cap: max allowed %d | actual %d", orth_types.InstructionToStr(orth_types.InstructionStore), len(virtualMem)-1, offset))
 			}
-			// [1:] because index 0 is the offset itself
-			addToMem(&virtualMem, offset, preview[1:]...)
+			// the value keeps the width of the pointee, a byte when the pointer has none
+			value := preview[1]
+			if helpers.IsInt(value.Operator) {
+				stored := helpers.ToInt(value.Operator)
+				value.Operator.SymbolName = pointeeType(operation, orth_types.StdI8)
+				value.Operator.Operand = strconv.Itoa(truncate(stored, elementWidth(value.Operator)))
+			}
+			addToMem(&virtualMem, offset, value)
 			// remove from main stack
 			stack.rmv(2)
 		case orth_types.InstructionLoad:
 			preview := stack.peek(1)
-			stack.push(preview...)
 			stack.rmv(1)
+			// without a pointee, `,` reads a single byte, which is not sign extended
+			stack.push(loadPointee(virtualMem, preview[0], operation, pointeeType(operation, orth_types.StdI8), false))
 		case orth_types.InstructionLoadStay:
 			preview := stack.peek(1)
 			stack.push(preview...)
 		case orth_types.InstructionCall:
 			callingProcSchema, err := program.FindProc(operation)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
+				panic(err)
 			}
 			preview := stack.peek(len(callingProcSchema.InParamsAmount))
 			for i, stackItem := range preview {
 				if callingProcSchema.InParamsAmount[i].Operator.Operand != stackItem.Operator.SymbolName {
-					fmt.Fprintf(os.Stderr, "Proc param required type %q but got %q", callingProcSchema.InParamsAmount[i].Operator.Operand, stackItem.Operator.SymbolName)
-					os.Exit(1)
+					panic(fmt.Errorf("Proc param required type %q but got %q", callingProcSchema.InParamsAmount[i].Operator.Operand, stackItem.Operator.SymbolName))
+				}
+			}
+			// the params are left on the stack, where the proc takes them from
+			returns = append(returns, ip)
+			ip = procs[operation.Operator.Operand]
+		case orth_types.InstructionProcAddress:
+			stack.push(operation)
+		case orth_types.InstructionCallIndirect:
+			address := stack.peek(1)[0]
+			stack.rmv(1)
+			checkSignatureParams(&stack, operation)
+			procAddress, ok := procs[address.Operator.Operand]
+			if !ok {
+				panic(fmt.Errorf("cannot call %q, it is not the address of a proc", address.Operator.Operand))
+			}
+			returns = append(returns, ip)
+			ip = procAddress
+		case orth_types.InstructionInvoke:
+			operation = operation.Links["extern"]
+			fallthrough
+		case orth_types.InstructionAsm:
+			// foreign code can not be simulated, only its declared stack effect
+			removeSignatureParams(&stack, operation)
+			for i := 0; ; i++ {
+				outParam, ok := operation.Links[fmt.Sprintf("proc_out_param_%d", i)]
+				if !ok {
+					break
 				}
+				stack.push(orth_types.Operation{
+					Instruction: orth_types.InstructionPush,
+					Context:     operation.Context,
+					Operator: orth_types.Operand{
+						SymbolName: outParam.Operator.Operand,
+						Operand:    "0",
+					},
+				})
 			}
-			// if param type checking went well, remove params from the main stack
-			stack.rmv(len(callingProcSchema.InParamsAmount))
 		case orth_types.InstructionEnd:
 			procAddress, closingProc := operation.Addresses[orth_types.InstructionProc]
 			if closingProc {
 				callingProcSchema, err := program.FindProc(program.Operations[procAddress])
 				if err != nil {
-					fmt.Fprintln(os.Stderr, err)
-					os.Exit(1)
+					panic(err)
 				}
 
 				preview := stack.peek(len(callingProcSchema.OutParamsAmount))
 				for i, stackItem := range preview {
 					if callingProcSchema.OutParamsAmount[i].Operator.Operand != stackItem.Operator.SymbolName {
-						fmt.Fprintf(os.Stderr, "Proc return required type %q but got %q", callingProcSchema.OutParamsAmount[i].Operator.Operand, stackItem.Operator.SymbolName)
-						os.Exit(1)
+						panic(fmt.Errorf("Proc return required type %q but got %q", callingProcSchema.OutParamsAmount[i].Operator.Operand, stackItem.Operator.SymbolName))
 					}
 				}
+
+				if program.Operations[procAddress].Operator.Operand == "main" {
+					if len(preview) == 0 {
+						return 0, nil
+					}
+					status := helpers.ToInt(preview[0].Operator)
+					if !exitStatusInRange(status) {
+						panic(fmt.Errorf("exit status '%d' out of range for %q\n", status, runtime.GOOS))
+					}
+					return status, nil
+				}
+				// the returns are left on the stack for the caller
+				ip = returns[len(returns)-1]
+				returns = returns[:len(returns)-1]
+			}
+			if whileAddress, closingWhile := operation.Addresses[orth_types.InstructionWhile]; closingWhile {
+				// back to the condition, which follows the `while`
+				ip = whileAddress
+			}
+		case orth_types.InstructionIf:
+			preview := stack.peek(1)
+			stack.rmv(1)
+			if !truthy(preview[0].Operator) {
+				// the else branch starts right after its `else`, without one there is nothing to run up to the `end`
+				ip, _ = operation.PrioritizeAddress()
+			}
+		case orth_types.InstructionElse:
+			// reached at the end of the if branch
+			ip = operation.Addresses[orth_types.InstructionEnd]
+		case orth_types.InstructionDo:
+			preview := stack.peek(1)
+			stack.rmv(1)
+			if !truthy(preview[0].Operator) {
+				ip = operation.Addresses[orth_types.InstructionEnd]
 			}
 		case orth_types.InstructionHold:
-			var varAddress int
-			if localAddr, ok := operation.Addresses[orth_types.InstructionVar]; ok {
-				varAddress = localAddr
-			} else if globalAddr, ok := operation.Addresses[orth_types.InstructionConst]; ok {
-				varAddress = globalAddr
+			variable, ok := operation.Links["hold_local"]
+			if !ok {
+				variable = operation.Links["hold_mult"]
 			}
-
 			stack.push(orth_types.Operation{
 				Instruction: orth_types.InstructionPush,
+				Context:     operation.Context,
 				Operator: orth_types.Operand{
-					SymbolName: orth_types.StdAddress,
-					Operand:    fmt.Sprint(varAddress),
+					SymbolName: orth_types.PointerType(variable.Links["variable_value"].Operator.SymbolName),
+					Operand:    fmt.Sprint(variables[variableKey{variable.Context, variable.Operator.Operand}]),
 				},
 			})
+		case orth_types.InstructionCast:
+			preview := stack.peek(1)
+			stack.rmv(1)
+			preview[0].Operator.SymbolName = operation.Operator.SymbolName
+			stack.push(preview[0])
 		case orth_types.FunctionDumpMem:
 			preview := stack.peek(2)
 			for _, item := range preview {
 				if !helpers.IsInt(item.Operator) {
-					fmt.Fprintf(os.Stderr, "cannot have type %q used for %q instruction", item.Operator.SymbolName, orth_types.InstructionToStr(orth_types.FunctionDumpMem))
-					os.Exit(1)
+					panic(fmt.Errorf("cannot have type %q used for %q instruction", item.Operator.SymbolName, orth_types.InstructionToStr(orth_types.FunctionDumpMem)))
 				}
 			}
 			stack.rmv(2)
@@ -254,12 +670,10 @@ func SimulateStack(program *orth_types.Program) {
 			stack.rmv(2)
 
 			if !helpers.IsNumeric(preview[0].Operator) {
-				fmt.Fprintf(os.Stderr, "cannot have type %q as LShift value", preview[0].Operator.SymbolName)
-				os.Exit(1)
+				panic(fmt.Errorf("cannot have type %q as LShift value", preview[0].Operator.SymbolName))
 			}
 			if !helpers.IsInt(preview[1].Operator) {
-				fmt.Fprintf(os.Stderr, "cannot have type %q as LShift amount", preview[1].Operator.SymbolName)
-				os.Exit(1)
+				panic(fmt.Errorf("cannot have type %q as LShift amount", preview[1].Operator.SymbolName))
 			}
 
 			var shiftResult orth_types.Operand
@@ -278,12 +692,10 @@ func SimulateStack(program *orth_types.Program) {
 			stack.rmv(2)
 
 			if !helpers.IsNumeric(preview[0].Operator) {
-				fmt.Fprintf(os.Stderr, "cannot have type %q as %q value", preview[0].Operator.SymbolName, orth_types.InstructionToStr(orth_types.InstructionRShift))
-				os.Exit(1)
+				panic(fmt.Errorf("cannot have type %q as %q value", preview[0].Operator.SymbolName, orth_types.InstructionToStr(orth_types.InstructionRShift)))
 			}
 			if !helpers.IsInt(preview[1].Operator) {
-				fmt.Fprintf(os.Stderr, "cannot have type %q for %q amount", preview[1].Operator.SymbolName, orth_types.InstructionToStr(orth_types.InstructionRShift))
-				os.Exit(1)
+				panic(fmt.Errorf("cannot have type %q for %q amount", preview[1].Operator.SymbolName, orth_types.InstructionToStr(orth_types.InstructionRShift)))
 			}
 
 			var shiftResult orth_types.Operand
@@ -313,21 +725,171 @@ func SimulateStack(program *orth_types.Program) {
 		case orth_types.InstructionExit:
 			preview := stack.peek(1)
 			if !helpers.IsInt(preview[0].Operator) {
-				fmt.Fprintln(os.Stderr, "'exit' only accepts integer values")
-				os.Exit(1)
+				panic(errors.New("'exit' only accepts integer values"))
 			}
-			exitCode := helpers.ToInt(preview[0].Operator)
-			os.Exit(exitCode)
-		case orth_types.InstructionDeref:
+			panic(simulationExit(helpers.ToInt(preview[0].Operator)))
+		case orth_types.InstructionAssert:
 			preview := stack.peek(1)
-			addr, ok := helpers.ToAddress(preview[0].Operator)
-
+			if !helpers.IsInt(preview[0].Operator) && !helpers.IsBool(preview[0].Operator) {
+				panic(fmt.Errorf("'assert' only accepts bool or integer values, got %q\n", preview[0].Operator.SymbolName))
+			}
+			stack.rmv(1)
+			if !truthy(preview[0].Operator) {
+				message := orth_types.AssertionMessage(operation)
+				if unquoted, err := strconv.Unquote(`"` + message + `"`); err == nil {
+					message = unquoted
+				}
+				files.write(2, []byte(message))
+				return orth_types.ASSERT_EXIT_CODE, nil
+			}
+		case orth_types.FunctionGetEnv:
+			name, err := loadBytes(virtualMem, stack.peek(1)[0], -1)
+			if err != nil {
+				panic(err)
+			}
+			stack.rmv(1)
+			value, ok := os.LookupEnv(string(name))
 			if !ok {
-				fmt.Fprintf(os.Stderr, "cannot non addressable value for instruction %q\n", orth_types.InstructionToStr(orth_types.InstructionDeref))
-				os.Exit(1)
+				pushInteger(&stack, operation, 0)
+				break
+			}
+			// escaped like a string written in the source, so it is read back as the same bytes
+			quoted := strconv.Quote(value)
+			stack.push(orth_types.Operation{
+				Instruction: orth_types.InstructionPushStr,
+				Context:     operation.Context,
+				Operator: orth_types.Operand{
+					SymbolName: orth_types.StdSTR,
+					Operand:    quoted[1 : len(quoted)-1],
+				},
+			})
+		case orth_types.InstructionWith:
+			// argv and envp are arrays of pointers, which the virtual mem can not hold
+			if strings.HasPrefix(operation.Operator.Operand, orth_types.StdCli) {
+				panic(fmt.Errorf("%q can not be simulated\n", orth_types.StdWith+" "+operation.Operator.Operand))
 			}
+			// params of called procs are already on the stack, the ones of `main` are zeroed like in the compiled program
+			if len(returns) != 0 {
+				break
+			}
+			for i := 0; ; i++ {
+				param, ok := operation.Links[fmt.Sprintf("proc_param_%d", i)]
+				if !ok {
+					break
+				}
+				pushDeclared(&stack, operation, []orth_types.Operation{param})
+			}
+		case orth_types.FunctionGetChar:
+			pushInteger(&stack, operation, files.getChar())
+		case orth_types.FunctionReadInt:
+			pushInteger(&stack, operation, files.readInt())
+		case orth_types.FunctionReadLine:
+			preview := stack.peek(2)
+			stack.rmv(2)
+			line, ok := files.readLine()
+			if !ok {
+				pushInteger(&stack, operation, -1)
+				break
+			}
+			capacity := helpers.ToInt(preview[0].Operator)
+			length := min(len(line), capacity)
+			// the line is null terminated when it fits the buffer
+			content := line[:length]
+			if length < capacity {
+				content = append(content, 0)
+			}
+			if err := storeBytes(virtualMem, preview[1], content); err != nil {
+				panic(err)
+			}
+			pushInteger(&stack, operation, length)
+		case orth_types.FunctionFOpen:
+			preview := stack.peek(2)
+			path, err := loadBytes(virtualMem, preview[1], -1)
+			if err != nil {
+				panic(err)
+			}
+			stack.rmv(2)
+			pushInteger(&stack, operation, files.open(string(path), helpers.ToInt(preview[0].Operator)))
+		case orth_types.FunctionFRead:
+			preview := stack.peek(3)
+			buffer := make([]byte, helpers.ToInt(preview[0].Operator))
+			n := files.read(helpers.ToInt(preview[2].Operator), buffer)
+			if n > 0 {
+				if err := storeBytes(virtualMem, preview[1], buffer[:n]); err != nil {
+					panic(err)
+				}
+			}
+			stack.rmv(3)
+			pushInteger(&stack, operation, n)
+		case orth_types.FunctionFWrite:
+			preview := stack.peek(3)
+			buffer, err := loadBytes(virtualMem, preview[1], helpers.ToInt(preview[0].Operator))
+			if err != nil {
+				panic(err)
+			}
+			stack.rmv(3)
+			pushInteger(&stack, operation, files.write(helpers.ToInt(preview[2].Operator), buffer))
+		case orth_types.FunctionFClose:
+			preview := stack.peek(1)
+			files.close(helpers.ToInt(preview[0].Operator))
+			stack.rmv(1)
+		case orth_types.FunctionFSize:
+			preview := stack.peek(1)
+			stack.rmv(1)
+			pushInteger(&stack, operation, files.size(helpers.ToInt(preview[0].Operator)))
+		case orth_types.FunctionUnlink:
+			preview := stack.peek(1)
+			path, err := loadBytes(virtualMem, preview[0], -1)
+			if err != nil {
+				panic(err)
+			}
+			stack.rmv(1)
+			pushInteger(&stack, operation, unlink(string(path)))
+		case orth_types.FunctionPutStringFd:
+			preview := stack.peek(2)
+			content, err := loadBytes(virtualMem, preview[0], -1)
+			if err != nil {
+				panic(err)
+			}
+			files.write(helpers.ToInt(preview[1].Operator), content)
+			stack.rmv(2)
+		case orth_types.FunctionEWrite:
+			preview := stack.peek(1)
+			content, err := loadBytes(virtualMem, preview[0], -1)
+			if err != nil {
+				panic(err)
+			}
+			files.write(2, content)
+			stack.rmv(1)
+		case orth_types.InstructionIndexLoad:
+			array := operation.Links["array"]
+			preview := stack.peek(1)
+			index := checkArrayIndex(preview[0].Operator, array, operation.Instruction)
+			stack.rmv(1)
+
+			slot, _ := arrayElement(variables, array, index)
+			element := virtualMem[slot]
+			element.Context = operation.Context
+			stack.push(element)
+		case orth_types.InstructionIndexStore:
+			array := operation.Links["array"]
+			preview := stack.peek(2)
+			index := checkArrayIndex(preview[1].Operator, array, operation.Instruction)
+			stack.rmv(2)
 
-			stack.push(program.Operations[addr])
+			slot, width := arrayElement(variables, array, index)
+			element := preview[0]
+			element.Operator.SymbolName = array.Links["variable_value"].Operator.SymbolName
+			if helpers.IsInt(preview[0].Operator) {
+				element.Operator.Operand = strconv.Itoa(truncate(helpers.ToInt(preview[0].Operator), width))
+			}
+			virtualMem[slot] = element
+		case orth_types.InstructionDeref:
+			preview := stack.peek(1)
+			stack.rmv(1)
+			// without a pointee, `deref` reads a whole qword
+			stack.push(loadPointee(virtualMem, preview[0], operation, pointeeType(operation, orth_types.StdI64), true))
 		}
 	}
+	return 0, nil
 }
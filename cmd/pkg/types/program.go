@@ -2,6 +2,7 @@ package orth_types
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -10,6 +11,23 @@ const (
 	MAX_PROC_OUTPUT_COUNT = 32
 )
 
+// DEFAULT_MEM_SIZE is the size in bytes of the `mem` buffer when neither `-mem` nor `memory mem` are used
+const DEFAULT_MEM_SIZE uint = 640000
+
+// ASSERT_EXIT_CODE is the exit status of a program stopped by a failed `assert`, same as an aborted C program
+const ASSERT_EXIT_CODE = 134
+
+// AssertionMessage builds the text reported by a failed `assert`, its operand being the `file:line:col` of the assertion.
+// The message keeps the escape sequences written in the source
+func AssertionMessage(assert Operation) string {
+	location := strconv.Quote(assert.Operator.Operand)
+	text := location[1:len(location)-1] + ": assertion failed"
+	if message, ok := assert.Links["assert_message"]; ok {
+		text += ": " + message.Operator.Operand
+	}
+	return text + `\n`
+}
+
 type Instruction uint16
 
 const (
@@ -67,6 +85,33 @@ const (
 	FunctionAlloc
 	FunctionFree
 	FunctionPutChar
+	InstructionIndexLoad
+	InstructionIndexStore
+	InstructionMemory
+	InstructionCast
+	InstructionEnum
+	InstructionProcAddress
+	InstructionCallIndirect
+	InstructionAsm
+	InstructionExtern
+	FunctionFOpen
+	FunctionFRead
+	FunctionFWrite
+	FunctionFClose
+	FunctionFSize
+	FunctionUnlink
+	FunctionPutStringFd
+	FunctionEWrite
+	FunctionGetChar
+	FunctionReadLine
+	FunctionReadInt
+	FunctionPutI64
+	FunctionPutHex
+	FunctionPutBin
+	FunctionPutBool
+	FunctionPutU64Pad
+	FunctionGetEnv
+	InstructionAssert
 	Skip
 	TotalOps
 )
@@ -75,60 +120,87 @@ var instructionNames map[Instruction]string
 
 func init() {
 	instructionNames = map[Instruction]string{
-		Skip:                "Skip",
-		InstructionPush:     "Push",
-		InstructionPushStr:  "PushStr",
-		InstructionSum:      "Sum",
-		InstructionMinus:    "Minus",
-		InstructionMult:     "Mult",
-		InstructionDiv:      "Div",
-		InstructionIf:       "If",
-		InstructionElse:     "Else",
-		InstructionEnd:      "End",
-		InstructionEqual:    "Equal",
-		InstructionLt:       "Lt",
-		InstructionGt:       "Gt",
-		InstructionNotEqual: "NotEqual",
-		InstructionDup:      "Dup",
-		InstructionTwoDup:   "TwoDup",
-		InstructionDo:       "Do",
-		InstructionDrop:     "Drop",
-		InstructionWhile:    "While",
-		InstructionSwap:     "Swap",
-		InstructionMod:      "Mod",
-		InstructionMem:      "Mem",
-		InstructionStore:    "Store",
-		InstructionLoad:     "Load",
-		InstructionLoadStay: "LoadStay",
-		InstructionFunc:     "Func",
-		InstructionCall:     "Call",
-		InstructionType:     "Type",
-		InstructionConst:    "Const",
-		InstructionVar:      "Var",
-		InstructionGvar:     "Gvar",
-		InstructionHold:     "Hold",
-		InstructionNop:      "Nop",
-		InstructionProc:     "Proc",
-		InstructionIn:       "In",
-		InstructionInvoke:   "Invoke",
-		InstructionLShift:   "LShift",
-		InstructionRShift:   "RShift",
-		InstructionLAnd:     "LAnd",
-		InstructionLOr:      "LOr",
-		InstructionOver:     "Over",
-		InstructionExit:     "Exit",
-		InstructionParam:    "Param",
-		InstructionWith:     ":",
-		InstructionOut:      "--",
-		InstructionDeref:    "Deref",
-		FunctionPutU64:      "PutU64",
-		FunctionPutString:   "PutString",
-		FunctionDumpMem:     "DumpMem",
-		FunctionSetNumber:   "SetNumber",
-		FunctionSetString:   "SetString",
-		FunctionAlloc:       "Alloc",
-		FunctionFree:        "Free",
-		FunctionPutChar:     "PutChar",
+		Skip:                    "Skip",
+		InstructionPush:         "Push",
+		InstructionPushStr:      "PushStr",
+		InstructionSum:          "Sum",
+		InstructionMinus:        "Minus",
+		InstructionMult:         "Mult",
+		InstructionDiv:          "Div",
+		InstructionIf:           "If",
+		InstructionElse:         "Else",
+		InstructionEnd:          "End",
+		InstructionEqual:        "Equal",
+		InstructionLt:           "Lt",
+		InstructionGt:           "Gt",
+		InstructionNotEqual:     "NotEqual",
+		InstructionDup:          "Dup",
+		InstructionTwoDup:       "TwoDup",
+		InstructionDo:           "Do",
+		InstructionDrop:         "Drop",
+		InstructionWhile:        "While",
+		InstructionSwap:         "Swap",
+		InstructionMod:          "Mod",
+		InstructionMem:          "Mem",
+		InstructionStore:        "Store",
+		InstructionLoad:         "Load",
+		InstructionLoadStay:     "LoadStay",
+		InstructionFunc:         "Func",
+		InstructionCall:         "Call",
+		InstructionType:         "Type",
+		InstructionConst:        "Const",
+		InstructionVar:          "Var",
+		InstructionGvar:         "Gvar",
+		InstructionHold:         "Hold",
+		InstructionNop:          "Nop",
+		InstructionProc:         "Proc",
+		InstructionIn:           "In",
+		InstructionInvoke:       "Invoke",
+		InstructionLShift:       "LShift",
+		InstructionRShift:       "RShift",
+		InstructionLAnd:         "LAnd",
+		InstructionLOr:          "LOr",
+		InstructionOver:         "Over",
+		InstructionExit:         "Exit",
+		InstructionParam:        "Param",
+		InstructionWith:         ":",
+		InstructionOut:          "--",
+		InstructionDeref:        "Deref",
+		FunctionPutU64:          "PutU64",
+		FunctionPutString:       "PutString",
+		FunctionDumpMem:         "DumpMem",
+		FunctionSetNumber:       "SetNumber",
+		FunctionSetString:       "SetString",
+		FunctionAlloc:           "Alloc",
+		FunctionFree:            "Free",
+		FunctionPutChar:         "PutChar",
+		InstructionIndexLoad:    "IndexLoad",
+		InstructionIndexStore:   "IndexStore",
+		InstructionMemory:       "Memory",
+		InstructionCast:         "Cast",
+		InstructionEnum:         "Enum",
+		InstructionProcAddress:  "ProcAddress",
+		InstructionCallIndirect: "CallIndirect",
+		InstructionAsm:          "Asm",
+		InstructionExtern:       "Extern",
+		FunctionFOpen:           "FOpen",
+		FunctionFRead:           "FRead",
+		FunctionFWrite:          "FWrite",
+		FunctionFClose:          "FClose",
+		FunctionFSize:           "FSize",
+		FunctionUnlink:          "Unlink",
+		FunctionPutStringFd:     "PutStringFd",
+		FunctionEWrite:          "EWrite",
+		FunctionGetChar:         "GetChar",
+		FunctionReadLine:        "ReadLine",
+		FunctionReadInt:         "ReadInt",
+		FunctionPutI64:          "PutI64",
+		FunctionPutHex:          "PutHex",
+		FunctionPutBin:          "PutBin",
+		FunctionPutBool:         "PutBool",
+		FunctionPutU64Pad:       "PutU64Pad",
+		FunctionGetEnv:          "GetEnv",
+		InstructionAssert:       "Assert",
 	}
 
 	if len(instructionNames) != int(TotalOps)-1 {
@@ -146,11 +218,32 @@ func InstructionToStr(inst Instruction) string {
 // Program is the main struct for a transpiled
 // orth code into machine code
 type Program struct {
-	Warnings   []CompilerMessage
-	Error      []error
-	Variables  []Operation
-	Constants  []Operation
-	Operations []Operation
+	Warnings      []CompilerMessage
+	Error         []error
+	Variables     []Operation
+	Constants     []Operation
+	MemoryRegions []Operation
+	Operations    []Operation
+	MemSize       uint
+	Documentation []Documentation
+}
+
+// Documentation is the `##` comment written above the declaration of a proc, const, var or enum
+type Documentation struct {
+	Kind        string
+	Name        string
+	Declaration string
+	Text        string
+	File        string
+	Line        int
+}
+
+// MemCapacity returns the size in bytes of the `mem` buffer
+func (p *Program) MemCapacity() uint {
+	if p.MemSize == 0 {
+		return DEFAULT_MEM_SIZE
+	}
+	return p.MemSize
 }
 
 type ProcedureSchema struct {
@@ -163,19 +256,25 @@ func (p *Program) FindProc(operation Operation) (ProcedureSchema, error) {
 
 	for callingProcedureIndex, op := range p.Operations {
 		if op.Operator.Operand == operation.Operator.Operand && op.Instruction == InstructionProc {
+			// the params are declared between the proc name and its `in`, in the order they are pushed
 			for _, operation := range p.Operations[callingProcedureIndex:] {
+				if operation.Instruction == InstructionIn {
+					break
+				}
 				if operation.Instruction == InstructionWith {
-					for k, v := range operation.Links {
-						if !strings.HasPrefix(k, "proc_param_") {
-							continue
+					for i := 0; ; i++ {
+						v, ok := operation.Links[fmt.Sprintf("proc_param_%d", i)]
+						if !ok {
+							break
 						}
 						callingProcedureArguments = append(callingProcedureArguments, v)
 					}
 				}
 				if operation.Instruction == InstructionOut {
-					for k, v := range operation.Links {
-						if !strings.HasPrefix(k, "proc_out_param_") {
-							continue
+					for i := 0; ; i++ {
+						v, ok := operation.Links[fmt.Sprintf("proc_out_param_%d", i)]
+						if !ok {
+							break
 						}
 						callingProcedureOutParams = append(callingProcedureOutParams, v)
 					}
@@ -226,7 +325,21 @@ const (
 	High
 )
 
+func (d WarnDegree) String() string {
+	switch d {
+	case Commom:
+		return "common"
+	case High:
+		return "high"
+	}
+	return "minor"
+}
+
+// CompilerMessage is a warning found on the program, `Code` being its `ORTH_WARN_XX` and
+// `Location` the place it refers to, empty when it is not bound to one
 type CompilerMessage struct {
-	Type    WarnDegree
-	Message string
+	Type     WarnDegree
+	Code     string
+	Message  string
+	Location Location
 }
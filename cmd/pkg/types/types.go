@@ -3,6 +3,8 @@ package orth_types
 import (
 	"errors"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
 const FileType = "orth"
@@ -42,6 +44,7 @@ const (
 	StdRNT           string = "rnt"
 	StdParam         string = "param"
 	StdMem           string = "mem"
+	StdMemory        string = "memory"
 	StdType          string = "type"
 	StdConst         string = "const"
 	StdVar           string = "var"
@@ -70,20 +73,64 @@ const (
 	StdProcInParams  string = ":"
 	StdAddress       string = "addr"
 	StdBitwise       string = "bitwise"
+	StdAssign        string = "="
+	StdArrayOpen     string = "["
+	StdArrayClose    string = "]"
+	StdIndexLoad     string = "idx@"
+	StdIndexStore    string = "idx!"
+	StdPtr           string = "ptr"
+	StdCast          string = "cast"
+	StdEnum          string = "enum"
+	StdEnumCount     string = "count"
+	StdSizeOf        string = "sizeof"
+	StdProcAddress   string = "&"
+	StdCallIndirect  string = "call*"
+	StdAsm           string = "asm"
+	StdExtern        string = "extern"
+	StdWith          string = "with"
+	StdCli           string = "cli"
+	StdEnv           string = "env"
+	StdOut           string = "out"
+	StdAssert        string = "assert"
+	StdPub           string = "pub"
 )
 
 // builtin functions/symbols
 const (
-	StdPutUint   string = "putui"
-	StdPutStr    string = "puts"
-	StdSetNumber string = "set_number"
-	StdSetStr    string = "set_string"
-	StdDumpMem   string = "dump_mem"
-	StdPutChar   string = "put_char"
-	StdDeref     string = "deref"
-	StdExit      string = "exit"
-	StdAlloc     string = "alloc"
-	StdFree      string = "free"
+	StdPutUint    string = "putui"
+	StdPutUintPad string = "putui_pad"
+	StdPutInt     string = "puti"
+	StdPutHex     string = "putx"
+	StdPutBin     string = "putb"
+	StdPutBool    string = "putb_bool"
+	StdPutStr     string = "puts"
+	StdSetNumber  string = "set_number"
+	StdSetStr     string = "set_string"
+	StdDumpMem    string = "dump_mem"
+	StdPutChar    string = "put_char"
+	StdDeref      string = "deref"
+	StdExit       string = "exit"
+	StdAlloc      string = "alloc"
+	StdFree       string = "free"
+	StdFOpen      string = "fopen"
+	StdFRead      string = "fread"
+	StdFWrite     string = "fwrite"
+	StdFClose     string = "fclose"
+	StdFSize      string = "fsize"
+	StdUnlink     string = "unlink"
+	StdPutStrFd   string = "puts_fd"
+	StdEWrite     string = "ewrite"
+	StdGetChar    string = "getchar"
+	StdReadLine   string = "read_line"
+	StdReadInt    string = "read_int"
+	StdGetEnv     string = "getenv"
+	StdSyscall0   string = "syscall0"
+	StdSyscall1   string = "syscall1"
+	StdSyscall2   string = "syscall2"
+	StdSyscall3   string = "syscall3"
+	StdSyscall4   string = "syscall4"
+	StdSyscall5   string = "syscall5"
+	StdSyscall6   string = "syscall6"
 )
 
 // some shit I don't remember
@@ -125,6 +172,7 @@ type Operation struct {
 	Context     *Context
 	Links       map[string]Operation
 	Addresses   map[Instruction]int
+	Location    Location
 }
 
 func (op *Operation) PrioritizeAddress() (int, error) {
@@ -138,6 +186,21 @@ func (op *Operation) PrioritizeAddress() (int, error) {
 	return 0, errors.New("no addresses")
 }
 
+// IsArray checks whenever a var/const was declared as a fixed-size array
+func (op *Operation) IsArray() bool {
+	_, isArray := op.Links["array_length"]
+	return isArray
+}
+
+// ArrayLength returns the amount of elements of a var/const declared as an array
+func (op *Operation) ArrayLength() int {
+	length, err := strconv.Atoi(op.Links["array_length"].Operator.Operand)
+	if err != nil {
+		return 0
+	}
+	return length
+}
+
 func (op *Operation) IsString() bool {
 	_, isString := GlobalTypes[STRING][op.Operator.SymbolName]
 	return isString
@@ -179,6 +242,8 @@ type SliceOf[T comparable] struct {
 type File[T comparable] struct {
 	Name      string
 	CodeBlock T
+	// Source is the code of the file as it was written, before its `pub` modifiers are removed and the declarations of a module renamed
+	Source T
 }
 
 func (ctx *Context) MountFullLengthContext(name string) string {
@@ -189,6 +254,25 @@ func (ctx *Context) MountFullLengthContext(name string) string {
 	return name
 }
 
+// moduleScopePrefix starts the names of the declarations of a module, which are scoped like contexts
+const moduleScopePrefix = "c?_module_"
+
+// ModuleScope names the scope of the declarations of a module the way contexts are named. Ex: "c?_module_geometry_0$"
+func ModuleScope(module string, order int) string {
+	return moduleScopePrefix + module + "_" + strconv.Itoa(order) + "$"
+}
+
+// UnscopedName removes the scope of the module a declaration was renamed with. Ex: "c?_module_geometry_0$_area" is "area"
+func UnscopedName(name string) string {
+	if !strings.HasPrefix(name, moduleScopePrefix) {
+		return name
+	}
+	if _, unscoped, ok := strings.Cut(name, "$_"); ok {
+		return unscoped
+	}
+	return name
+}
+
 func (ctx *Context) GetVaraible(variable string, program *Program) (*Operation, error) {
 	for ctx != nil {
 		for _, decls := range ctx.Declarations {
@@ -253,8 +337,26 @@ func IsValidTypeOp(o *Operation) bool {
 		GlobalTypes[MEM][o.Operator.SymbolName] != ""
 }
 
+// PointerType builds the type of a pointer to a value of type `pointee`. Ex: "ptr i32"
+func PointerType(pointee string) string {
+	return StdPtr + " " + pointee
+}
+
+// IsPointerType checks if a type was built by PointerType
+func IsPointerType(t string) bool {
+	return strings.HasPrefix(t, StdPtr+" ")
+}
+
+// PointeeType returns the type pointed by a pointer type
+func PointeeType(t string) string {
+	return strings.TrimPrefix(t, StdPtr+" ")
+}
+
 // IsValidTypeSybl checks whenever a variable has a know or unknow type
 func IsValidTypeSybl(s string) bool {
+	if IsPointerType(s) {
+		return IsValidTypeSybl(PointeeType(s))
+	}
 	return GlobalTypes[TYPE][s] != "" ||
 		GlobalTypes[INTS][s] != "" ||
 		GlobalTypes[FLOATS][s] != "" ||
@@ -267,12 +369,16 @@ func IsValidTypeSybl(s string) bool {
 
 func GrabType(o string) string {
 	switch {
+	case IsPointerType(o):
+		return o
 	case GlobalTypes[INTS][o] != INVALIDTYPE:
 		return GlobalTypes[INTS][o]
 	case GlobalTypes[STRING][o] != INVALIDTYPE:
 		return GlobalTypes[STRING][o]
 	case GlobalTypes[FLOATS][o] != INVALIDTYPE:
 		return GlobalTypes[FLOATS][o]
+	case GlobalTypes[BOOL][o] != INVALIDTYPE:
+		return GlobalTypes[BOOL][o]
 	case GlobalTypes[RNT][o] != INVALIDTYPE:
 		return GlobalTypes[RNT][o]
 	default:
@@ -295,15 +401,73 @@ func (o Operand) GrabRootType() string {
 	return ret
 }
 
+// TokenKind is the lexical category of a token
+type TokenKind uint8
+
+const (
+	TokenIdentifier TokenKind = iota
+	TokenKeyword
+	TokenType
+	TokenNumber
+	TokenString
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenKeyword:
+		return "keyword"
+	case TokenType:
+		return "type"
+	case TokenNumber:
+		return "number"
+	case TokenString:
+		return "string"
+	default:
+		return "identifier"
+	}
+}
+
 type Vec2DString struct {
 	Index    int
 	ValidPos bool
 	Token    string
+	Kind     TokenKind
 }
 
+// StringEnum is a token found at line `Index` of `File`, its column being `Content.Index`.
+// Tokens written in the body of a macro keep where the macro was expanded in `ExpandedFrom`
 type StringEnum struct {
-	Index   int
-	Content Vec2DString
+	File         string
+	Index        int
+	Content      Vec2DString
+	ExpandedFrom *MacroExpansion
+	IncludedFrom *StringEnum
+	Doc          string
+}
+
+// Location is where a token was written, lines starting at 1 and columns at 0
+type Location struct {
+	File   string
+	Line   int
+	Column int
+	Length int
+}
+
+// Location returns where the token was written, spanning over its whole text
+func (v StringEnum) Location() Location {
+	return Location{
+		File:   v.File,
+		Line:   v.Index,
+		Column: v.Content.Index,
+		Length: len(v.Content.Token),
+	}
+}
+
+// MacroExpansion links the tokens expanded from a macro to its definition and to the call that expanded them
+type MacroExpansion struct {
+	Macro      string
+	Definition StringEnum
+	Use        StringEnum
 }
 
 type (
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+	"orth/cmd/core/orth_debug"
 	testhelper "orth/tests/test_helper"
 	"strings"
 	"testing"
@@ -207,3 +209,1021 @@ func TestCommandLineArguments(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestArrays(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestArrays.orth")
+	expected := testhelper.LoadExpected("TestArrays")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestArrays")
+		t.FailNow()
+	}
+}
+
+func TestMemoryRegions(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestMemoryRegions.orth")
+	expected := testhelper.LoadExpected("TestMemoryRegions")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestMemoryRegions")
+		t.FailNow()
+	}
+}
+
+func TestTypedPointers(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestTypedPointers.orth")
+	expected := testhelper.LoadExpected("TestTypedPointers")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestTypedPointers")
+		t.FailNow()
+	}
+}
+
+func TestTypedPointersSimulated(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestTypedPointers.orth", "")
+	expected := testhelper.LoadExpected("TestTypedPointers")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestTypedPointersSimulated")
+		t.FailNow()
+	}
+}
+
+func TestPointerLoads(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestPointerLoads.orth")
+	expected := testhelper.LoadExpected("TestPointerLoads")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestPointerLoads")
+		t.FailNow()
+	}
+}
+
+func TestPointerLoadsSimulated(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestPointerLoads.orth", "")
+	expected := testhelper.LoadExpected("TestPointerLoads")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestPointerLoadsSimulated")
+		t.FailNow()
+	}
+}
+
+func TestPointerArithmeticMismatch(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestPointerArithmeticMismatch.orth")
+	expected := testhelper.LoadExpected("TestPointerArithmeticMismatch")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestPointerArithmeticMismatch")
+		t.FailNow()
+	}
+}
+
+func TestEnums(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestEnums.orth")
+	expected := testhelper.LoadExpected("TestEnums")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestEnums")
+		t.FailNow()
+	}
+}
+
+func TestEnumBranches(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestEnumBranches.orth")
+	expected := testhelper.LoadExpected("TestEnumBranches")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestEnumBranches")
+		t.FailNow()
+	}
+}
+
+func TestEnumBranchesSimulated(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestEnumBranches.orth", "")
+	expected := testhelper.LoadExpected("TestEnumBranches")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestEnumBranchesSimulated")
+		t.FailNow()
+	}
+}
+
+func TestWarnMessageWhenEnumComparisonMissesMembers(t *testing.T) {
+	*orth_debug.WarnEnum = true
+	defer func() { *orth_debug.WarnEnum = false }()
+
+	_, warnings := testhelper.PrepareComp("./repo/TestWarnMessageWhenEnumComparisonMissesMembers.orth")
+	expected := testhelper.LoadExpected("TestWarnMessageWhenEnumComparisonMissesMembers")
+
+	programWarnings := strings.Join(testhelper.WarningSliceToStringSlice(warnings), "\n")
+	if programWarnings != expected {
+		testhelper.DumpOutput(programWarnings, "TestWarnMessageWhenEnumComparisonMissesMembers")
+		t.FailNow()
+	}
+}
+
+func TestConstantExpressions(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestConstantExpressions.orth")
+	expected := testhelper.LoadExpected("TestConstantExpressions")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestConstantExpressions")
+		t.FailNow()
+	}
+}
+
+func TestProcPointers(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestProcPointers.orth")
+	expected := testhelper.LoadExpected("TestProcPointers")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestProcPointers")
+		t.FailNow()
+	}
+}
+
+func TestProcPointerSignatureMismatch(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestProcPointerSignatureMismatch.orth")
+	expected := testhelper.LoadExpected("TestProcPointerSignatureMismatch")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestProcPointerSignatureMismatch")
+		t.FailNow()
+	}
+}
+
+func TestInlineAssembly(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestInlineAssembly.orth")
+	expected := testhelper.LoadExpected("TestInlineAssembly")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestInlineAssembly")
+		t.FailNow()
+	}
+}
+
+func TestInlineAssemblyWrongBackend(t *testing.T) {
+	*orth_debug.Compile = "nasm"
+	defer func() { *orth_debug.Compile = "" }()
+
+	errors, _ := testhelper.PrepareComp("./repo/TestInlineAssemblyWrongBackend.orth")
+	expected := testhelper.LoadExpected("TestInlineAssemblyWrongBackend")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestInlineAssemblyWrongBackend")
+		t.FailNow()
+	}
+}
+
+func TestForeignFunctions(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestForeignFunctions.orth")
+	expected := testhelper.LoadExpected("TestForeignFunctions")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestForeignFunctions")
+		t.FailNow()
+	}
+}
+
+func TestExternMultipleReturns(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestExternMultipleReturns.orth")
+	expected := testhelper.LoadExpected("TestExternMultipleReturns")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestExternMultipleReturns")
+		t.FailNow()
+	}
+}
+
+func TestExternUnsupportedTarget(t *testing.T) {
+	*orth_debug.Compile = "nasm"
+	defer func() { *orth_debug.Compile = "" }()
+
+	errors, _ := testhelper.PrepareComp("./repo/TestExternUnsupportedTarget.orth")
+	expected := testhelper.LoadExpected("TestExternUnsupportedTarget")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestExternUnsupportedTarget")
+		t.FailNow()
+	}
+}
+
+func TestSyscallUnsupported(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestSyscallUnsupported.orth")
+	expected := testhelper.LoadExpected("TestSyscallUnsupported")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestSyscallUnsupported")
+		t.FailNow()
+	}
+}
+
+func TestFileIO(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestFileIO.orth")
+	expected := testhelper.LoadExpected("TestFileIO")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestFileIO")
+		t.FailNow()
+	}
+}
+
+func TestFileIOLoop(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestFileIOLoop.orth")
+	expected := testhelper.LoadExpected("TestFileIOLoop")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestFileIOLoop")
+		t.FailNow()
+	}
+}
+
+func TestFileIOLoopSimulated(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestFileIOLoop.orth", "")
+	expected := testhelper.LoadExpected("TestFileIOLoop")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestFileIOLoopSimulated")
+		t.FailNow()
+	}
+}
+
+func TestReadInput(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestReadInput.orth", "./input/TestReadInput.txt")
+	expected := testhelper.LoadExpected("TestReadInput")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestReadInput")
+		t.FailNow()
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestReadLines.orth", "./input/TestReadLines.txt")
+	expected := testhelper.LoadExpected("TestReadLines")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestReadLines")
+		t.FailNow()
+	}
+}
+
+func TestNumericPrinting(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestNumericPrinting.orth")
+	expected := testhelper.LoadExpected("TestNumericPrinting")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestNumericPrinting")
+		t.FailNow()
+	}
+}
+
+func TestNumericPrintingSimulated(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestNumericPrinting.orth", "")
+	expected := testhelper.LoadExpected("TestNumericPrinting")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestNumericPrintingSimulated")
+		t.FailNow()
+	}
+}
+
+func TestNumericTable(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestNumericTable.orth")
+	expected := testhelper.LoadExpected("TestNumericTable")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestNumericTable")
+		t.FailNow()
+	}
+}
+
+func TestNumericTableSimulated(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestNumericTable.orth", "")
+	expected := testhelper.LoadExpected("TestNumericTable")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestNumericTableSimulated")
+		t.FailNow()
+	}
+}
+
+func TestPutsEscapes(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestPutsEscapes.orth")
+	expected := testhelper.LoadExpected("TestPutsEscapes")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestPutsEscapes")
+		t.FailNow()
+	}
+}
+
+func TestPutsEscapesSimulated(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestPutsEscapes.orth", "")
+	expected := testhelper.LoadExpected("TestPutsEscapes")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestPutsEscapesSimulated")
+		t.FailNow()
+	}
+}
+
+func TestOperators(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestOperators.orth")
+	expected := testhelper.LoadExpected("TestOperators")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestOperators")
+		t.FailNow()
+	}
+}
+
+func TestOperatorsSimulated(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestOperators.orth", "")
+	expected := testhelper.LoadExpected("TestOperators")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestOperatorsSimulated")
+		t.FailNow()
+	}
+}
+
+func TestDup(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestDup.orth")
+	expected := testhelper.LoadExpected("TestDup")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestDup")
+		t.FailNow()
+	}
+}
+
+func TestDupSimulated(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestDup.orth", "")
+	expected := testhelper.LoadExpected("TestDup")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestDupSimulated")
+		t.FailNow()
+	}
+}
+
+func TestStackUnderflowSimulated(t *testing.T) {
+	_, _, err := testhelper.SimulateOutput("./repo/TestStackUnderflow.orth", "")
+	expected := testhelper.LoadExpected("TestStackUnderflowSimulated")
+
+	if err == nil || err.Error() != expected {
+		testhelper.DumpOutput(fmt.Sprint(err), "TestStackUnderflowSimulated")
+		t.FailNow()
+	}
+}
+
+func TestProcParamsSimulated(t *testing.T) {
+	programOutput, status, err := testhelper.SimulateOutput("./repo/TestProcParams.orth", "")
+
+	if programOutput != "" || status != 0 || err != nil {
+		testhelper.DumpOutput(programOutput, "TestProcParamsSimulated")
+		t.FailNow()
+	}
+}
+
+func TestArrayStore(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestArrayStore.orth")
+	expected := testhelper.LoadExpected("TestArrayStore")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestArrayStore")
+		t.FailNow()
+	}
+}
+
+func TestArrayStoreSimulated(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestArrayStore.orth", "")
+	expected := testhelper.LoadExpected("TestArrayStore")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestArrayStoreSimulated")
+		t.FailNow()
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestExitStatus.orth")
+	expected := testhelper.LoadExpected("TestExitStatus")
+	expectedStatus := testhelper.LoadExpectedExitCode("TestExitStatus")
+
+	programOutput, status := testhelper.ExecOutputWithStatus()
+
+	if programOutput != expected || status != expectedStatus {
+		testhelper.DumpOutput(programOutput, "TestExitStatus")
+		t.FailNow()
+	}
+}
+
+func TestExitStatusSimulated(t *testing.T) {
+	programOutput, status, _ := testhelper.SimulateOutput("./repo/TestExitStatus.orth", "")
+	expected := testhelper.LoadExpected("TestExitStatus")
+	expectedStatus := testhelper.LoadExpectedExitCode("TestExitStatus")
+
+	if programOutput != expected || status != expectedStatus {
+		testhelper.DumpOutput(programOutput, "TestExitStatusSimulated")
+		t.FailNow()
+	}
+}
+
+func TestExitStatusOutAmount(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestExitStatusOutAmount.orth")
+	expected := testhelper.LoadExpected("TestExitStatusOutAmount")
+	expectedStatus := testhelper.LoadExpectedExitCode("TestExitStatusOutAmount")
+
+	programOutput, status := testhelper.ExecOutputWithStatus()
+
+	if programOutput != expected || status != expectedStatus {
+		testhelper.DumpOutput(programOutput, "TestExitStatusOutAmount")
+		t.FailNow()
+	}
+}
+
+func TestExitStatusOutAmountSimulated(t *testing.T) {
+	programOutput, status, _ := testhelper.SimulateOutput("./repo/TestExitStatusOutAmount.orth", "")
+	expected := testhelper.LoadExpected("TestExitStatusOutAmount")
+	expectedStatus := testhelper.LoadExpectedExitCode("TestExitStatusOutAmount")
+
+	if programOutput != expected || status != expectedStatus {
+		testhelper.DumpOutput(programOutput, "TestExitStatusOutAmountSimulated")
+		t.FailNow()
+	}
+}
+
+func TestExitStatusComputed(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestExitStatusComputed.orth")
+	expectedStatus := testhelper.LoadExpectedExitCode("TestExitStatusComputed")
+
+	_, status := testhelper.ExecOutputWithStatus()
+
+	if len(errors) != 0 || status != expectedStatus {
+		testhelper.DumpOutput(fmt.Sprint(status), "TestExitStatusComputed")
+		t.FailNow()
+	}
+}
+
+func TestExitStatusComputedSimulated(t *testing.T) {
+	_, status, err := testhelper.SimulateOutput("./repo/TestExitStatusComputed.orth", "")
+	expectedStatus := testhelper.LoadExpectedExitCode("TestExitStatusComputed")
+
+	if err != nil || status != expectedStatus {
+		testhelper.DumpOutput(fmt.Sprint(status, err), "TestExitStatusComputedSimulated")
+		t.FailNow()
+	}
+}
+
+func TestMainExitStatusType(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestMainExitStatusType.orth")
+	expected := testhelper.LoadExpected("TestMainExitStatusType")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestMainExitStatusType")
+		t.FailNow()
+	}
+}
+
+func TestGetEnv(t *testing.T) {
+	t.Setenv("ORTH_TEST_GETENV", "from the environment")
+
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestGetEnv.orth", "")
+	expected := testhelper.LoadExpected("TestGetEnv")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestGetEnv")
+		t.FailNow()
+	}
+}
+
+func TestGetEnvIgnoreCase(t *testing.T) {
+	t.Setenv("ORTH_TEST_GETENV", "from the environment")
+
+	testhelper.PrepareComp("./repo/TestGetEnvIgnoreCase.orth")
+	expected := testhelper.LoadExpected("TestGetEnvIgnoreCase")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestGetEnvIgnoreCase")
+		t.FailNow()
+	}
+}
+
+func TestWithCliOutsideMain(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestWithCliOutsideMain.orth")
+	expected := testhelper.LoadExpected("TestWithCliOutsideMain")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestWithCliOutsideMain")
+		t.FailNow()
+	}
+}
+
+func TestAssert(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestAssert.orth")
+	expected := testhelper.LoadExpected("TestAssert")
+	expectedStatus := testhelper.LoadExpectedExitCode("TestAssert")
+
+	programOutput, status := testhelper.ExecOutputWithStatus()
+
+	if programOutput != expected || status != expectedStatus {
+		testhelper.DumpOutput(programOutput, "TestAssert")
+		t.FailNow()
+	}
+}
+
+func TestAssertSimulated(t *testing.T) {
+	programOutput, status, _ := testhelper.SimulateOutput("./repo/TestAssert.orth", "")
+	expected := testhelper.LoadExpected("TestAssertSimulated")
+	expectedStatus := testhelper.LoadExpectedExitCode("TestAssert")
+
+	if programOutput != expected || status != expectedStatus {
+		testhelper.DumpOutput(programOutput, "TestAssertSimulated")
+		t.FailNow()
+	}
+}
+
+func TestAssertBranch(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestAssertBranch.orth")
+	expected := testhelper.LoadExpected("TestAssertBranch")
+	expectedStatus := testhelper.LoadExpectedExitCode("TestAssertBranch")
+
+	programOutput, status := testhelper.ExecOutputWithStatus()
+
+	if programOutput != expected || status != expectedStatus {
+		testhelper.DumpOutput(programOutput, "TestAssertBranch")
+		t.FailNow()
+	}
+}
+
+func TestAssertBranchSimulated(t *testing.T) {
+	programOutput, status, _ := testhelper.SimulateOutput("./repo/TestAssertBranch.orth", "")
+	expected := testhelper.LoadExpected("TestAssertBranchSimulated")
+	expectedStatus := testhelper.LoadExpectedExitCode("TestAssertBranch")
+
+	if programOutput != expected || status != expectedStatus {
+		testhelper.DumpOutput(programOutput, "TestAssertBranchSimulated")
+		t.FailNow()
+	}
+}
+
+func TestAssertStripped(t *testing.T) {
+	*orth_debug.NoAssert = true
+	defer func() { *orth_debug.NoAssert = false }()
+
+	programOutput, status, _ := testhelper.SimulateOutput("./repo/TestAssert.orth", "")
+	expected := testhelper.LoadExpected("TestAssertStripped")
+
+	if programOutput != expected || status != 0 {
+		testhelper.DumpOutput(programOutput, "TestAssertStripped")
+		t.FailNow()
+	}
+}
+
+func TestScanner(t *testing.T) {
+	expected := testhelper.LoadExpected("TestScanner")
+
+	for _, fileName := range []string{"TestScanner", "TestScannerCRLF"} {
+		tokens := testhelper.LexOutput("./repo/" + fileName + ".orth")
+		if tokens != expected {
+			testhelper.DumpOutput(tokens, fileName)
+			t.FailNow()
+		}
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestUnterminatedString.orth")
+	expected := testhelper.LoadExpected("TestUnterminatedString")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestUnterminatedString")
+		t.FailNow()
+	}
+}
+
+func TestDefineTokens(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestDefineTokens.orth", "")
+	expected := testhelper.LoadExpected("TestDefineTokens")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestDefineTokens")
+		t.FailNow()
+	}
+}
+
+func TestDefineBeforeUse(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestDefineBeforeUse.orth")
+	expected := testhelper.LoadExpected("TestDefineBeforeUse")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestDefineBeforeUse")
+		t.FailNow()
+	}
+}
+
+func TestDefineRedefinition(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestDefineRedefinition.orth")
+	expected := testhelper.LoadExpected("TestDefineRedefinition")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestDefineRedefinition")
+		t.FailNow()
+	}
+}
+
+func TestMacros(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestMacros.orth")
+	expected := testhelper.LoadExpected("TestMacros")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestMacros")
+		t.FailNow()
+	}
+}
+
+func TestMacroExpansion(t *testing.T) {
+	tokens := testhelper.LexOutput("./repo/TestMacros.orth")
+	expected := testhelper.LoadExpected("TestMacroExpansion")
+
+	if tokens != expected {
+		testhelper.DumpOutput(tokens, "TestMacroExpansion")
+		t.FailNow()
+	}
+}
+
+func TestMacroRecursion(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestMacroRecursion.orth")
+	expected := testhelper.LoadExpected("TestMacroRecursion")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestMacroRecursion")
+		t.FailNow()
+	}
+}
+
+func TestMacroErrorLocation(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestMacroErrorLocation.orth")
+	expected := testhelper.LoadExpected("TestMacroErrorLocation")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestMacroErrorLocation")
+		t.FailNow()
+	}
+}
+
+func TestConditionals(t *testing.T) {
+	// `-D DEBUG -D LEVEL=2`, each use of the flag being kept
+	orth_debug.D.Set("DEBUG")
+	orth_debug.D.Set("LEVEL=2")
+	defer func() { *orth_debug.D = nil }()
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestConditionals.orth", "")
+	expected := testhelper.LoadExpected("TestConditionals")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestConditionals")
+		t.FailNow()
+	}
+}
+
+func TestConditionalUnclosed(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestConditionalUnclosed.orth")
+	expected := testhelper.LoadExpected("TestConditionalUnclosed")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestConditionalUnclosed")
+		t.FailNow()
+	}
+}
+
+func TestInclude(t *testing.T) {
+	*orth_debug.I = "./repo/includes/lib"
+	defer func() { *orth_debug.I = "" }()
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestInclude.orth", "")
+	expected := testhelper.LoadExpected("TestInclude")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestInclude")
+		t.FailNow()
+	}
+}
+
+func TestIncludeCycle(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestIncludeCycle.orth")
+	expected := testhelper.LoadExpected("TestIncludeCycle")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestIncludeCycle")
+		t.FailNow()
+	}
+}
+
+func TestIncludeError(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestIncludeError.orth")
+	expected := testhelper.LoadExpected("TestIncludeError")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestIncludeError")
+		t.FailNow()
+	}
+}
+
+func TestModules(t *testing.T) {
+	testhelper.PrepareComp("./repo/TestModules.orth")
+	expected := testhelper.LoadExpected("TestModules")
+
+	programOutput := testhelper.ExecOutput()
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestModules")
+		t.FailNow()
+	}
+}
+
+func TestModulesSimulated(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestModules.orth", "")
+	expected := testhelper.LoadExpected("TestModules")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestModulesSimulated")
+		t.FailNow()
+	}
+}
+
+func TestModuleMangling(t *testing.T) {
+	tokens := testhelper.LexOutput("./repo/TestModules.orth")
+	expected := testhelper.LoadExpected("TestModuleMangling")
+
+	if tokens != expected {
+		testhelper.DumpOutput(tokens, "TestModuleMangling")
+		t.FailNow()
+	}
+}
+
+func TestModulePrivate(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestModulePrivate.orth")
+	expected := testhelper.LoadExpected("TestModulePrivate")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestModulePrivate")
+		t.FailNow()
+	}
+}
+
+func TestBlockComments(t *testing.T) {
+	programOutput, _, _ := testhelper.SimulateOutput("./repo/TestBlockComments.orth", "")
+	expected := testhelper.LoadExpected("TestBlockComments")
+
+	if programOutput != expected {
+		testhelper.DumpOutput(programOutput, "TestBlockComments")
+		t.FailNow()
+	}
+}
+
+func TestBlockCommentUnterminated(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestBlockCommentUnterminated.orth")
+	expected := testhelper.LoadExpected("TestBlockCommentUnterminated")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestBlockCommentUnterminated")
+		t.FailNow()
+	}
+}
+
+func TestDocMarkdown(t *testing.T) {
+	reference := testhelper.DocOutput("./repo/TestDocComments.orth", "md")
+	expected := testhelper.LoadExpected("TestDocMarkdown")
+
+	if reference != expected {
+		testhelper.DumpOutput(reference, "TestDocMarkdown")
+		t.FailNow()
+	}
+}
+
+func TestDocHTML(t *testing.T) {
+	reference := testhelper.DocOutput("./repo/TestDocComments.orth", "html")
+	expected := testhelper.LoadExpected("TestDocHTML")
+
+	if reference != expected {
+		testhelper.DumpOutput(reference, "TestDocHTML")
+		t.FailNow()
+	}
+}
+
+func TestDocModules(t *testing.T) {
+	reference := testhelper.DocOutput("./repo/TestDocModules.orth", "md")
+	expected := testhelper.LoadExpected("TestDocModules")
+
+	if reference != expected {
+		testhelper.DumpOutput(reference, "TestDocModules")
+		t.FailNow()
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestErrorRecovery.orth")
+	expected := testhelper.LoadExpected("TestErrorRecovery")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestErrorRecovery")
+		t.FailNow()
+	}
+}
+
+func TestTruncatedStatements(t *testing.T) {
+	for _, name := range []string{"TestTruncatedCall", "TestTruncatedVar", "TestTruncatedVarValue", "TestTruncatedMemory", "TestTruncatedIndexLoad"} {
+		errors, _ := testhelper.PrepareComp("./repo/" + name + ".orth")
+		expected := testhelper.LoadExpected(name)
+
+		programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+		if programErros != expected {
+			testhelper.DumpOutput(programErros, name)
+			t.Fail()
+		}
+	}
+}
+
+func TestConstArrayStore(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestConstArrayStore.orth")
+	expected := testhelper.LoadExpected("TestConstArrayStore")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestConstArrayStore")
+		t.FailNow()
+	}
+}
+
+func TestArrayValueRange(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestArrayValueRange.orth")
+	expected := testhelper.LoadExpected("TestArrayValueRange")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestArrayValueRange")
+		t.FailNow()
+	}
+}
+
+func TestUnbalancedBlocks(t *testing.T) {
+	errors, _ := testhelper.PrepareComp("./repo/TestUnbalancedBlocks.orth")
+	expected := testhelper.LoadExpected("TestUnbalancedBlocks")
+
+	programErros := strings.Join(testhelper.ErrSliceToStringSlice(errors), "\n")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestUnbalancedBlocks")
+		t.FailNow()
+	}
+}
+
+func TestRenderedErrors(t *testing.T) {
+	programErros := testhelper.RenderedErrors("./repo/TestUnbalancedBlocks.orth")
+	expected := testhelper.LoadExpected("TestRenderedErrors")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestRenderedErrors")
+		t.FailNow()
+	}
+}
+
+func TestRenderedErrorsMacro(t *testing.T) {
+	programErros := testhelper.RenderedErrors("./repo/TestMacroErrorLocation.orth")
+	expected := testhelper.LoadExpected("TestRenderedErrorsMacro")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestRenderedErrorsMacro")
+		t.FailNow()
+	}
+}
+
+func TestRenderedErrorsUnicode(t *testing.T) {
+	programErros := testhelper.RenderedErrors("./repo/TestRenderedErrorsUnicode.orth")
+	expected := testhelper.LoadExpected("TestRenderedErrorsUnicode")
+
+	if programErros != expected {
+		testhelper.DumpOutput(programErros, "TestRenderedErrorsUnicode")
+		t.FailNow()
+	}
+}
+
+func TestRenderedWarnings(t *testing.T) {
+	*orth_debug.WarnEnum = true
+	defer func() { *orth_debug.WarnEnum = false }()
+
+	programWarnings := testhelper.RenderedErrors("./repo/TestWarnMessageWhenEnumComparisonMissesMembers.orth")
+	expected := testhelper.LoadExpected("TestRenderedWarnings")
+
+	if programWarnings != expected {
+		testhelper.DumpOutput(programWarnings, "TestRenderedWarnings")
+		t.FailNow()
+	}
+}
+
+func TestJSONDiagnostics(t *testing.T) {
+	programDiagnostics := testhelper.JSONDiagnostics("./repo/TestUnbalancedBlocks.orth")
+	expected := testhelper.LoadExpected("TestJSONDiagnostics")
+
+	if programDiagnostics != expected {
+		testhelper.DumpOutput(programDiagnostics, "TestJSONDiagnostics")
+		t.FailNow()
+	}
+}
+
+func TestJSONDiagnosticsWarnings(t *testing.T) {
+	*orth_debug.WarnEnum = true
+	defer func() { *orth_debug.WarnEnum = false }()
+
+	programDiagnostics := testhelper.JSONDiagnostics("./repo/TestWarnMessageWhenEnumComparisonMissesMembers.orth")
+	expected := testhelper.LoadExpected("TestJSONDiagnosticsWarnings")
+
+	if programDiagnostics != expected {
+		testhelper.DumpOutput(programDiagnostics, "TestJSONDiagnosticsWarnings")
+		t.FailNow()
+	}
+}
@@ -8,10 +8,13 @@ import (
 	"orth/cmd/core/embedded/optimizer"
 	"orth/cmd/core/lexer"
 	"orth/cmd/core/orth_debug"
+	"orth/cmd/pkg/simulation"
 	orth_types "orth/cmd/pkg/types"
 	"os"
 	"os/exec"
 	"regexp"
+	"strconv"
+	"strings"
 )
 
 func ErrSliceToStringSlice(errs []error) []string {
@@ -31,8 +34,93 @@ func WarningSliceToStringSlice(warns []orth_types.CompilerMessage) []string {
 }
 
 func PrepareComp(fileName string) ([]error, []orth_types.CompilerMessage) {
-	strProgram := lexer.LoadProgramFromFile(fileName)
-	lexedFiles := lexer.LexFile(strProgram)
+	program := prepareProgram(fileName)
+
+	if len(program.Error) != 0 {
+		return program.Error, program.Warnings
+	}
+
+	if err := embedded.Compile(program, *orth_debug.Compile); err != nil {
+		program.Error = append(program.Error, err)
+	}
+
+	return program.Error, program.Warnings
+}
+
+// RenderedErrors renders the warnings and errors found on a program the way the compiler writes them, without colors
+func RenderedErrors(fileName string) string {
+	program := prepareProgram(fileName)
+	return orth_debug.RenderDiagnostics(program.Error, program.Warnings, false)
+}
+
+// JSONDiagnostics writes the warnings and errors found on a program as `-diagnostics=json` does
+func JSONDiagnostics(fileName string) string {
+	program := prepareProgram(fileName)
+	var out bytes.Buffer
+	if err := orth_debug.WriteJSONDiagnostics(&out, program.Error, program.Warnings); err != nil {
+		return err.Error()
+	}
+	return out.String()
+}
+
+// SimulateOutput runs a program on the simulator feeding `inputFile`, when not empty, as its stdin,
+// returning everything written to stdout and stderr, the exit status and the runtime error that stopped it
+func SimulateOutput(fileName, inputFile string) (string, int, error) {
+	program := prepareProgram(fileName)
+	if len(program.Error) != 0 {
+		return strings.Join(ErrSliceToStringSlice(program.Error), "\n"), 1, nil
+	}
+
+	if inputFile != "" {
+		input, _ := os.Open(inputFile)
+		defer input.Close()
+		simulation.Input = input
+		defer func() { simulation.Input = os.Stdin }()
+	}
+
+	output, _ := os.CreateTemp("", "orth_sim")
+	defer os.Remove(output.Name())
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = output, output
+	status, err := simulation.SimulateStack(&program)
+	os.Stdout, os.Stderr = stdout, stderr
+	output.Close()
+
+	content, _ := os.ReadFile(output.Name())
+	return string(content), status, err
+}
+
+// LexOutput scans and preprocesses a program returning one `line:col kind token` entry per token or the lexing error
+func LexOutput(fileName string) string {
+	lexedFiles, err := lexer.LoadProgramFromFile(fileName)
+	if err != nil {
+		return err.Error()
+	}
+
+	tokens := make([]string, 0)
+	for _, file := range lexedFiles {
+		for _, token := range *file.CodeBlock.Slice {
+			tokens = append(tokens, fmt.Sprintf("%d:%d %s %s", token.Index, token.Content.Index, token.Content.Kind, token.Content.Token))
+		}
+	}
+	return strings.Join(tokens, "\n")
+}
+
+// DocOutput renders the reference of the `##` comments of a program in `format` or returns the error found
+func DocOutput(fileName, format string) string {
+	lexedFiles, err := lexer.LoadProgramFromFile(fileName)
+	if err != nil {
+		return err.Error()
+	}
+	reference, err := embedded.RenderDocumentation(embedded.CollectDocumentation(lexedFiles), format)
+	if err != nil {
+		return err.Error()
+	}
+	return reference
+}
+
+func prepareProgram(fileName string) orth_types.Program {
+	lexedFiles, err := lexer.LoadProgramFromFile(fileName)
 
 	parsedOperations := make(chan orth_types.Pair[orth_types.Operation, error])
 
@@ -41,48 +129,67 @@ func PrepareComp(fileName string) ([]error, []orth_types.CompilerMessage) {
 		Warnings:   make([]orth_types.CompilerMessage, 0),
 		Error:      make([]error, 0),
 	}
+	if err != nil {
+		program.Error = append(program.Error, err)
+		return program
+	}
 
+	program.Documentation = embedded.CollectDocumentation(lexedFiles)
 	go embedded.ParseTokenAsOperation(lexedFiles, parsedOperations)
 
 	analyzerOperations := make([]orth_types.Operation, 0)
 	for parsedOperation := range parsedOperations {
 		if parsedOperation.Right != nil {
 			program.Error = append(program.Error, parsedOperation.Right)
-			break
+			continue
 		}
 		parsedOperation.Left = embedded_helpers.LinkVariableToValue(parsedOperation.Left, &analyzerOperations, &program)
 		analyzerOperations = append(analyzerOperations, parsedOperation.Left)
 	}
 
 	if len(program.Error) != 0 {
-		return program.Error, program.Warnings
+		return program
 	}
 
 	optimizedOperation, warnings := optimizer.AnalyzeAndOptimizeOperations(analyzerOperations)
 	program.Warnings = append(program.Warnings, warnings...)
 	program.Operations = append(program.Operations, optimizedOperation...)
 
-	program, err := embedded.CrossReferenceBlocks(program)
+	program = embedded.CrossReferenceBlocks(program)
+	if len(program.Error) != 0 {
+		return program
+	}
+
+	program, err = embedded.TypeCheckPointers(program)
 	if err != nil {
 		program.Error = append(program.Error, err)
 	}
 
-	if len(program.Error) != 0 {
-		return program.Error, program.Warnings
-	}
+	return program
+}
 
-	embedded.Compile(program, *orth_debug.Compile)
+func ExecOutput() (programOutput string) {
+	execOutputExe := exec.Command(`.\output.exe`)
+	var out bytes.Buffer
+	execOutputExe.Stdout = &out
 
-	return program.Error, program.Warnings
+	execOutputExe.Run()
+	programOutput = out.String()
+	return
 }
 
-func ExecOutput() (programOutput string) {
+// ExecOutputWithStatus runs the compiled program returning its output and exit status
+func ExecOutputWithStatus() (programOutput string, exitStatus int) {
 	execOutputExe := exec.Command(`.\output.exe`)
 	var out bytes.Buffer
 	execOutputExe.Stdout = &out
 
 	execOutputExe.Run()
 	programOutput = out.String()
+	exitStatus = -1
+	if execOutputExe.ProcessState != nil {
+		exitStatus = execOutputExe.ProcessState.ExitCode()
+	}
 	return
 }
 
@@ -103,6 +210,16 @@ func LoadExpected(fileName string) string {
 	return rgx.ReplaceAllString(string(expected), "")
 }
 
+// LoadExpectedExitCode reads the exit status expected from a test, 0 when there is no `.exit` file
+func LoadExpectedExitCode(fileName string) int {
+	expected, err := os.ReadFile(fmt.Sprintf("./expected/%s.exit", fileName))
+	if err != nil {
+		return 0
+	}
+	status, _ := strconv.Atoi(strings.TrimSpace(string(expected)))
+	return status
+}
+
 func DumpOutput(out, fileName string) {
 	dumpFile, _ := os.Create(fmt.Sprintf("./dumps/%s.txt", fileName))
 	dumpFile.WriteString(out)